@@ -2,6 +2,7 @@ package main_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"path"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/antsrp/house_service/internal/domain/models"
 	"github.com/antsrp/house_service/internal/setup"
@@ -34,8 +36,11 @@ func init() {
 }
 
 func TestMain(m *testing.M) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	//h, dbConnection, logger, err := setup.Setup("TEST_DB")
-	h, srvSettings, dbConnection, logger, err := setup.Setup("DB")
+	h, srvSettings, dbConnection, logger, err := setup.Setup(ctx, "DB")
 	if err != nil {
 		log.Fatal(err.Error())
 		return
@@ -74,7 +79,7 @@ const (
 func TestAddHouse(t *testing.T) {
 	year := 2023
 	developer := `PEEK`
-	input := models.HouseCreateRequest{Address: "addr1", Year: &year, Developer: &developer}
+	input := models.HouseCreateRequest{Address: fmt.Sprintf("addr %d", time.Now().UnixNano()), Year: &year, Developer: &developer} // addresses of houses are unique
 	data, _ := json.Marshal(input)
 	req, _ := http.NewRequest(http.MethodPost, addr+"/house/create", bytes.NewBuffer(data))
 	req.Header.Set("Authorization", "Bearer "+moderatorToken)
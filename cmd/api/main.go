@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 
 	"log"
@@ -9,7 +10,10 @@ import (
 )
 
 func main() {
-	h, _, dbConnection, logger, err := setup.Setup("DB")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h, _, dbConnection, logger, err := setup.Setup(ctx, "DB")
 	if err != nil {
 		log.Fatal(err.Error())
 		return
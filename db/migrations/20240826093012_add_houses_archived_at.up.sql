@@ -0,0 +1 @@
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_status_check;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_status_check;
+ALTER TABLE flats ADD CONSTRAINT flats_status_check
+CHECK (status IN ('created', 'on moderation', 'approved', 'declined', 'withdrawn'));
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_previous_status_check;
+ALTER TABLE flats DROP COLUMN IF EXISTS previous_status;
+ALTER TABLE flats DROP COLUMN IF EXISTS moderation_started_at;
+ALTER TABLE flats DROP COLUMN IF EXISTS moderator_id;
\ No newline at end of file
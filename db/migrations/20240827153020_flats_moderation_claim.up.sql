@@ -0,0 +1,6 @@
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS moderator_id uuid REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS moderation_started_at TIMESTAMPTZ;
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS previous_status VARCHAR(20);
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_previous_status_check;
+ALTER TABLE flats ADD CONSTRAINT flats_previous_status_check
+CHECK (previous_status IN ('created', 'approved', 'declined'));
\ No newline at end of file
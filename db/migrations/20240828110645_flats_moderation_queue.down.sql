@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_flats_status;
+
+ALTER TABLE flats DROP COLUMN IF EXISTS created_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_flats_status ON flats(status);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_flat_moderation_events_flat;
+DROP TABLE IF EXISTS flat_moderation_events;
\ No newline at end of file
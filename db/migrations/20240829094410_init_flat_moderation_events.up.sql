@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS flat_moderation_events
+(
+    id SERIAL NOT NULL PRIMARY KEY,
+    flat_id INTEGER NOT NULL REFERENCES flats(id) ON DELETE CASCADE,
+    moderator_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL,
+    decline_reason VARCHAR(500),
+    comment TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_flat_moderation_events_flat ON flat_moderation_events(flat_id, created_at);
\ No newline at end of file
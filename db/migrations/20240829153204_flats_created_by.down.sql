@@ -0,0 +1 @@
+ALTER TABLE flats DROP COLUMN IF EXISTS created_by;
\ No newline at end of file
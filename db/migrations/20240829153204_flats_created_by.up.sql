@@ -0,0 +1 @@
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS created_by uuid REFERENCES users(id) ON DELETE SET NULL;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_flats_house_number;
+
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_living_area_check_total;
+ALTER TABLE flats DROP COLUMN IF EXISTS living_area;
+ALTER TABLE flats DROP COLUMN IF EXISTS total_area;
+ALTER TABLE flats DROP COLUMN IF EXISTS floor;
+ALTER TABLE flats DROP COLUMN IF EXISTS number;
\ No newline at end of file
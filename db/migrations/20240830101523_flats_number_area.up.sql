@@ -0,0 +1,8 @@
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS number INTEGER CHECK (number > 0);
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS floor SMALLINT;
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS total_area NUMERIC(8, 2) CHECK (total_area > 0);
+ALTER TABLE flats ADD COLUMN IF NOT EXISTS living_area NUMERIC(8, 2) CHECK (living_area > 0);
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS flats_living_area_check_total;
+ALTER TABLE flats ADD CONSTRAINT flats_living_area_check_total CHECK (living_area <= total_area);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flats_house_number ON flats(house_id, number);
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE houses DROP CONSTRAINT IF EXISTS houses_coordinates_check;
+ALTER TABLE houses DROP COLUMN IF EXISTS amenities;
+ALTER TABLE houses DROP COLUMN IF EXISTS longitude;
+ALTER TABLE houses DROP COLUMN IF EXISTS latitude;
+ALTER TABLE houses DROP COLUMN IF EXISTS building_type;
+ALTER TABLE houses DROP COLUMN IF EXISTS floors;
\ No newline at end of file
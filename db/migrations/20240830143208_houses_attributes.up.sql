@@ -0,0 +1,7 @@
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS floors SMALLINT CHECK (floors > 0);
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS building_type VARCHAR(20)
+    CHECK (building_type IN ('brick', 'panel', 'monolith', 'monolith-brick', 'block', 'wood'));
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90);
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180);
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS amenities TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE houses ADD CONSTRAINT houses_coordinates_check CHECK ((latitude IS NULL) = (longitude IS NULL));
\ No newline at end of file
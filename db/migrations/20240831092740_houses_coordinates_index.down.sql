@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_houses_coordinates;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_houses_coordinates ON houses(latitude, longitude);
\ No newline at end of file
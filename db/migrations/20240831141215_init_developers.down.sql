@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_houses_developer_id;
+ALTER TABLE houses DROP COLUMN IF EXISTS developer_id;
+
+DROP INDEX IF EXISTS idx_developers_name;
+DROP TABLE IF EXISTS developers;
\ No newline at end of file
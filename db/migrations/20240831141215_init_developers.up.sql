@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS developers
+(
+    id SERIAL NOT NULL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_developers_name ON developers(LOWER(name));
+
+INSERT INTO developers (name)
+SELECT DISTINCT ON (LOWER(TRIM(developer))) TRIM(developer) FROM houses
+WHERE developer IS NOT NULL AND TRIM(developer) <> ''
+ORDER BY LOWER(TRIM(developer)), id
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS developer_id INTEGER REFERENCES developers(id);
+
+UPDATE houses SET developer_id = developers.id, developer = developers.name
+FROM developers WHERE LOWER(TRIM(houses.developer)) = LOWER(developers.name);
+
+CREATE INDEX IF NOT EXISTS idx_houses_developer_id ON houses(developer_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_houses_address_normalized;
+ALTER TABLE houses DROP COLUMN IF EXISTS address_normalized;
\ No newline at end of file
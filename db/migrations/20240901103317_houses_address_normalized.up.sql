@@ -0,0 +1,43 @@
+BEGIN;
+
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS address_normalized TEXT;
+
+-- mirrors address.Normalize: separators and abbreviations are the same as in internal/domain/address
+CREATE OR REPLACE FUNCTION normalize_address(address TEXT) RETURNS TEXT AS $$
+    SELECT COALESCE(string_agg(COALESCE(a.short, w.word), ' ' ORDER BY w.n), '')
+    FROM (
+        SELECT BTRIM(word, '-/') AS word, n
+        FROM (SELECT E' \t\n\x0B\f\r\u00A0\u2009\u202F.,;:!?"\'`()[]{}<>«»„“”‘’…–—_*&%@#№+=~^$|\\' AS separators) AS s,
+            unnest(string_to_array(translate(REPLACE(LOWER(address), 'ё', 'е'), s.separators, repeat(' ', length(s.separators))), ' '))
+            WITH ORDINALITY AS t(word, n)
+    ) AS w
+    LEFT JOIN (VALUES
+        ('улица', 'ул'), ('проспект', 'пр-кт'), ('пр-т', 'пр-кт'), ('просп', 'пр-кт'), ('переулок', 'пер'), ('площадь', 'пл'),
+        ('бульвар', 'б-р'), ('бул', 'б-р'), ('шоссе', 'ш'), ('набережная', 'наб'), ('пр-д', 'проезд'), ('дом', 'д'),
+        ('корпус', 'к'), ('корп', 'к'), ('строение', 'стр'), ('квартира', 'кв'), ('город', 'г'), ('street', 'st'),
+        ('avenue', 'ave'), ('av', 'ave'), ('road', 'rd'), ('boulevard', 'blvd'), ('building', 'bldg')
+    ) AS a(word, short) ON a.word = w.word
+    WHERE w.word <> ''
+$$ LANGUAGE SQL IMMUTABLE;
+
+UPDATE houses SET address_normalized = normalize_address(address);
+
+DROP FUNCTION normalize_address(TEXT);
+
+-- duplicates have to be resolved by hand, the unique index can't be created otherwise
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%s (ids %s)', address_normalized, ids), '; ') INTO duplicates FROM (
+        SELECT address_normalized, string_agg(id::TEXT, ', ' ORDER BY id) AS ids FROM houses
+        WHERE archived_at IS NULL GROUP BY address_normalized HAVING COUNT(*) > 1
+    ) AS d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'not archived houses have the same address, archive or rename them: %', duplicates;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_houses_address_normalized ON houses(address_normalized) WHERE archived_at IS NULL;
+
+COMMIT;
\ No newline at end of file
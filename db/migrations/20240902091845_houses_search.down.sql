@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_houses_search_vector;
+
+ALTER TABLE houses DROP COLUMN IF EXISTS search_vector;
\ No newline at end of file
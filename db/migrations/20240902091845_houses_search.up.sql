@@ -0,0 +1,5 @@
+ALTER TABLE houses ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', address), 'A') || setweight(to_tsvector('russian', COALESCE(developer, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_houses_search_vector ON houses USING GIN(search_vector);
\ No newline at end of file
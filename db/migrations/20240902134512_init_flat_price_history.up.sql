@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS flat_price_history
+(
+    id SERIAL NOT NULL PRIMARY KEY,
+    flat_id INTEGER NOT NULL REFERENCES flats(id) ON DELETE CASCADE,
+    old_price INTEGER NOT NULL,
+    new_price INTEGER NOT NULL,
+    changed_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_flat_price_history_flat ON flat_price_history(flat_id, changed_at);
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS revoked_tokens;
+
+CREATE TABLE IF NOT EXISTS tokens (
+    id SERIAL PRIMARY KEY,
+    user_id uuid UNIQUE REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    token VARCHAR(500) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
\ No newline at end of file
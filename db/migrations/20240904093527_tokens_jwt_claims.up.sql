@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS tokens;
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti uuid PRIMARY KEY,
+    user_id uuid REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
\ No newline at end of file
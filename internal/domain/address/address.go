@@ -0,0 +1,62 @@
+package address
+
+import (
+	"strings"
+	"unicode"
+)
+
+// separators split address into words. Migration 20240901103317 normalizes stored addresses by the same rules,
+// so separators and abbreviations are changed together with it
+const separators = " \t\n\v\f\r\u00a0\u2009\u202f" + `.,;:!?"'` + "`" + `()[]{}<>«»„“”‘’…–—_*&%@#№+=~^$|\`
+
+// abbreviations maps words of address to their canonical short forms
+var abbreviations = map[string]string{
+	"улица": "ул", "ул": "ул",
+	"проспект": "пр-кт", "пр-кт": "пр-кт", "пр-т": "пр-кт", "просп": "пр-кт",
+	"переулок": "пер", "пер": "пер",
+	"площадь": "пл", "пл": "пл",
+	"бульвар": "б-р", "б-р": "б-р", "бул": "б-р",
+	"шоссе": "ш", "ш": "ш",
+	"набережная": "наб", "наб": "наб",
+	"проезд": "проезд", "пр-д": "проезд",
+	"дом": "д", "д": "д",
+	"корпус": "к", "корп": "к", "к": "к",
+	"строение": "стр", "стр": "стр",
+	"квартира": "кв", "кв": "кв",
+	"город": "г", "г": "г",
+	"street": "st", "st": "st",
+	"avenue": "ave", "ave": "ave", "av": "ave",
+	"road": "rd", "rd": "rd",
+	"boulevard": "blvd", "blvd": "blvd",
+	"building": "bldg", "bldg": "bldg",
+}
+
+// Normalize returns canonical form of address: case-folded words separated by single spaces
+// without punctuation, with street types and parts of building replaced by their short forms
+func Normalize(address string) string {
+	address = strings.ReplaceAll(strings.ToLower(address), "ё", "е")
+	words := strings.FieldsFunc(address, func(r rune) bool {
+		return strings.ContainsRune(separators, r)
+	})
+
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.Trim(word, "-/")
+		if word == "" {
+			continue
+		}
+		if short, found := abbreviations[word]; found {
+			word = short
+		}
+		normalized = append(normalized, word)
+	}
+	return strings.Join(normalized, " ")
+}
+
+// Tokens splits text into case-folded words consisting of letters and digits only, it's used to build search queries
+func Tokens(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,34 @@
+package address_test
+
+import (
+	"testing"
+
+	"github.com/antsrp/house_service/internal/domain/address"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"  ул. Ленина,  д. 5 ", "ул ленина д 5"},
+		{"УЛИЦА ЛЕНИНА ДОМ 5", "ул ленина д 5"},
+		{"Невский проспект, 28", "невский пр-кт 28"},
+		{"Невский пр-т 28", "невский пр-кт 28"},
+		{"Лесной пр., 3", "лесной пр 3"}, // пр is ambiguous: it's used both for проспект and проезд
+		{"Лётчиков ул., д.12/1, корп.2", "летчиков ул д 12/1 к 2"},
+		{"Baker Street, 221B", "baker st 221b"},
+		{"«Мира» пр-кт — 7/2 (корп. 1)", "мира пр-кт 7/2 к 1"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.out, address.Normalize(test.in), "address %q", test.in)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	require.Equal(t, []string{"ул", "лесная", "12", "1"}, address.Tokens("ул. Лесная, 12/1"))
+	require.Equal(t, []string{"пр", "кт", "мира"}, address.Tokens("Пр-кт МИРА"))
+	require.Empty(t, address.Tokens(" :*& !"))
+}
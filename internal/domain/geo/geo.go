@@ -0,0 +1,80 @@
+package geo
+
+import "math"
+
+// EarthRadius is mean radius of the Earth in meters
+const EarthRadius = 6371000.0
+
+const metersPerDegree = EarthRadius * math.Pi / 180
+
+// Point is a location in degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Distance returns great-circle distance between points in meters by haversine formula
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat, dLon := lat2-lat1, radians(b.Longitude-a.Longitude)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Box is an area between two parallels and two meridians. If MinLongitude is greater than MaxLongitude, the box crosses the antimeridian
+type Box struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+func (b Box) Contains(p Point) bool {
+	if p.Latitude < b.MinLatitude || p.Latitude > b.MaxLatitude {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return p.Longitude >= b.MinLongitude || p.Longitude <= b.MaxLongitude
+	}
+	return p.Longitude >= b.MinLongitude && p.Longitude <= b.MaxLongitude
+}
+
+func (b Box) CrossesAntimeridian() bool {
+	return b.MinLongitude > b.MaxLongitude
+}
+
+// Around returns box containing all points within the radius in meters from the center, used to prefilter points before calculation of distance
+func Around(center Point, radius float64) Box {
+	dLat := radius / metersPerDegree
+	box := Box{
+		MinLatitude:  math.Max(center.Latitude-dLat, -90),
+		MaxLatitude:  math.Min(center.Latitude+dLat, 90),
+		MinLongitude: -180,
+		MaxLongitude: 180,
+	}
+	if box.MinLatitude == -90 || box.MaxLatitude == 90 { // pole is inside of the circle, all meridians are crossed
+		return box
+	}
+
+	dLon := math.Asin(math.Min(math.Sin(radians(dLat))/math.Cos(radians(center.Latitude)), 1)) * 180 / math.Pi
+	if dLon >= 180 {
+		return box
+	}
+	box.MinLongitude, box.MaxLongitude = normalizeLongitude(center.Longitude-dLon), normalizeLongitude(center.Longitude+dLon)
+	return box
+}
+
+func normalizeLongitude(lon float64) float64 {
+	if lon < -180 {
+		return lon + 360
+	}
+	if lon > 180 {
+		return lon - 360
+	}
+	return lon
+}
@@ -0,0 +1,31 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/antsrp/house_service/internal/domain/geo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	moscow, petersburg := geo.Point{Latitude: 55.7558, Longitude: 37.6173}, geo.Point{Latitude: 59.9343, Longitude: 30.3351}
+
+	require.InDelta(t, 634000, geo.Distance(moscow, petersburg), 3000)
+	require.InDelta(t, geo.Distance(moscow, petersburg), geo.Distance(petersburg, moscow), 1e-6)
+	require.Zero(t, geo.Distance(moscow, moscow))
+}
+
+func TestAround(t *testing.T) {
+	center := geo.Point{Latitude: 59.9343, Longitude: 30.3351}
+	box := geo.Around(center, 1000)
+	require.True(t, box.Contains(center))
+	require.True(t, box.Contains(geo.Point{Latitude: 59.9400, Longitude: 30.3400}))
+	require.False(t, box.Contains(geo.Point{Latitude: 59.9600, Longitude: 30.3351}))
+
+	box = geo.Around(geo.Point{Latitude: 0, Longitude: 179.999}, 1000)
+	require.True(t, box.CrossesAntimeridian())
+	require.True(t, box.Contains(geo.Point{Latitude: 0, Longitude: -179.999}))
+
+	box = geo.Around(geo.Point{Latitude: 89.999, Longitude: 0}, 1000)
+	require.True(t, box.Contains(geo.Point{Latitude: 89.9995, Longitude: 180}))
+}
@@ -1,6 +1,10 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type DummyLoginRequest struct {
 	UserType UserType `json:"user_type"`
@@ -11,15 +15,98 @@ type DummyLoginResponse struct {
 }
 
 type HouseCreateRequest struct {
-	Address   string  `json:"address"`
-	Year      *int    `json:"year"`
-	Developer *string `json:"developer"`
+	Address      string        `json:"address"`
+	Year         *int          `json:"year"`
+	Developer    *string       `json:"developer"`    // name of the developer, it's created if it doesn't exist
+	DeveloperID  *int          `json:"developer_id"` // takes precedence over the name
+	Floors       *int          `json:"floors"`
+	BuildingType *BuildingType `json:"building_type"`
+	Latitude     *float64      `json:"latitude"`
+	Longitude    *float64      `json:"longitude"`
+	Amenities    []Amenity     `json:"amenities"`
+
+	AddressNormalized string `json:"-"`
 }
 
 type HouseCreateResponse struct {
 	House
 }
 
+type HouseNearbyRequest struct {
+	Latitude  *float64 `form:"lat"`
+	Longitude *float64 `form:"lon"`
+	Radius    *float64 `form:"radius"` // in meters
+	Limit     int      `form:"limit"`
+}
+
+type HouseWithinRequest struct {
+	MinLatitude  *float64 `form:"min_lat"`
+	MinLongitude *float64 `form:"min_lon"` // greater than max_lon if the box crosses the antimeridian
+	MaxLatitude  *float64 `form:"max_lat"`
+	MaxLongitude *float64 `form:"max_lon"`
+	Limit        int      `form:"limit"`
+}
+
+type HouseLocation struct {
+	House
+	Distance      *float64 `json:"distance,omitempty"` // in meters from the requested point
+	ApprovedFlats int      `json:"approved_flats"`
+}
+
+type HouseLocationsResponse struct {
+	Houses []HouseLocation `json:"houses"`
+}
+
+type HouseStatsResponse struct {
+	HouseID     int                `json:"house_id"`
+	Statuses    map[FlatStatus]int `json:"statuses"` // count of flats by status
+	Prices      []RoomPriceStats   `json:"prices"`   // sorted by count of rooms
+	UpdatedAt   time.Time          `json:"updated_at"`
+	SinceUpdate int64              `json:"since_update"` // seconds since the last update of the house
+}
+
+type RoomPriceStats struct {
+	Rooms   int     `json:"rooms"`
+	Count   int     `json:"count"`
+	Min     int     `json:"min"`
+	Median  float64 `json:"median"`
+	Max     int     `json:"max"`
+	Average float64 `json:"average"`
+}
+
+type HouseSearchRequest struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit"`
+}
+
+type HouseSearchResult struct {
+	House
+	Rank float64 `json:"rank"`
+}
+
+type HouseSearchResponse struct {
+	Houses []HouseSearchResult `json:"houses"`
+}
+
+type HouseUpdateRequest struct {
+	ID           int           `json:"-"`
+	Address      *string       `json:"address"`
+	Year         *int          `json:"year"`
+	Developer    *string       `json:"developer"`    // name of the developer, it's created if it doesn't exist
+	DeveloperID  *int          `json:"developer_id"` // takes precedence over the name
+	Floors       *int          `json:"floors"`
+	BuildingType *BuildingType `json:"building_type"`
+	Latitude     *float64      `json:"latitude"`
+	Longitude    *float64      `json:"longitude"`
+	Amenities    []Amenity     `json:"amenities"` // replaces the whole set if it's not nil
+
+	AddressNormalized *string `json:"-"`
+}
+
+type HouseUpdateResponse struct {
+	House
+}
+
 type HouseGetFlatsRequest struct {
 	ID int `json:"id"`
 }
@@ -29,22 +116,107 @@ type HouseGetFlatsResponse struct {
 	Flats []Flat `json:"flats"`
 }
 
+type HouseListRequest struct {
+	Address     *string    `form:"address"`
+	Developer   *string    `form:"developer"`
+	DeveloperID *int       `form:"developer_id"`
+	YearFrom    *int       `form:"year_from"`
+	YearTo      *int       `form:"year_to"`
+	CreatedFrom *time.Time `form:"created_from"`
+	CreatedTo   *time.Time `form:"created_to"`
+	UpdatedFrom *time.Time `form:"updated_from"`
+	UpdatedTo   *time.Time `form:"updated_to"`
+	PageRequest
+}
+
+type HouseListResponse struct {
+	Houses     []House `json:"houses"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
 type FlatCreateRequest struct {
 	//ID      int  `json:"id"`
-	HouseID int  `json:"house_id"`
-	Price   *int `json:"price"`
-	Room    int  `json:"room"`
+	HouseID    int      `json:"house_id"`
+	Number     int      `json:"number"`
+	Floor      *int     `json:"floor"`
+	TotalArea  *float64 `json:"total_area"`
+	LivingArea *float64 `json:"living_area"`
+	Price      *int     `json:"price"`
+	Room       int      `json:"room"`
+
+	CreatedBy uuid.UUID `json:"-"`
 }
 
 type FlatCreateResponse struct {
 	Flat
 }
 
+type FlatListRequest struct {
+	HouseID   *int        `form:"house_id"`
+	PriceFrom *int        `form:"price_from"`
+	PriceTo   *int        `form:"price_to"`
+	Room      *int        `form:"room"`
+	Status    *FlatStatus `form:"status"`
+	PageRequest
+
+	CreatedBy *uuid.UUID `form:"-" json:"-"` // only flats of the user in all statuses
+}
+
+type FlatListResponse struct {
+	Flats      []Flat `json:"flats"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ModerationQueueRequest struct {
+	HouseID   *int    `form:"house_id"`
+	Developer *string `form:"developer"`
+	PageRequest
+}
+
+const (
+	FlatImportCSV   = "csv"
+	FlatImportJSONL = "jsonl"
+
+	MaxFlatImportRows = 1000
+)
+
+var FlatImportFormats = []string{FlatImportCSV, FlatImportJSONL}
+
+// FlatImportRow is a parsed line of imported file, rows with error are not created
+type FlatImportRow struct {
+	Line  int
+	Flat  FlatCreateRequest
+	Error string
+}
+
+type FlatImportLine struct {
+	Line   int    `json:"line"`
+	FlatID int    `json:"flat_id,omitempty"` // set for accepted line
+	Error  string `json:"error,omitempty"`   // reason of rejection
+}
+
+type FlatImportResponse struct {
+	Accepted int              `json:"accepted"`
+	Rejected int              `json:"rejected"`
+	Lines    []FlatImportLine `json:"lines"`
+}
+
 type FlatUpdateRequest struct {
-	ID     int         `json:"id"`
-	Price  *int        `json:"price"`
-	Room   int         `json:"room"`
-	Status *FlatStatus `json:"status"`
+	ID            int         `json:"id"`
+	Number        *int        `json:"number"`
+	Floor         *int        `json:"floor"`
+	TotalArea     *float64    `json:"total_area"`
+	LivingArea    *float64    `json:"living_area"`
+	Price         *int        `json:"price"`
+	Room          int         `json:"room"`
+	Status        *FlatStatus `json:"status"`
+	DeclineReason *string     `json:"decline_reason"`
+	Comment       *string     `json:"comment"`
+	UpdatedBy     uuid.UUID   `json:"-"` // saved in price history
+}
+
+type FlatPriceHistoryResponse struct {
+	History []PriceChange `json:"history"`
 }
 
 type FlatUpdateResponse struct {
@@ -54,10 +226,22 @@ type FlatUpdateResponse struct {
 type LoginRequest struct {
 	UserID   *uuid.UUID `json:"id"`
 	Password *string    `json:"password"`
+	Client   ClientInfo `json:"-"`
 }
 
 type LoginResponse struct {
 	DummyLoginResponse
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"` // expiration time of access token
+}
+
+type TokenRefreshRequest struct {
+	RefreshToken *string    `json:"refresh_token"`
+	Client       ClientInfo `json:"-"`
+}
+
+type SessionsResponse struct {
+	Sessions []Session `json:"sessions"`
 }
 
 type RegisterRequest struct {
@@ -73,3 +257,34 @@ type RegisterResponse struct {
 type SubscribeRequest struct {
 	Email *string `json:"email"`
 }
+
+type DeveloperCreateRequest struct {
+	Name string `json:"name"`
+}
+
+type DeveloperUpdateRequest struct {
+	ID   int     `json:"-"`
+	Name *string `json:"name"`
+}
+
+type DeveloperListResponse struct {
+	Developers []Developer `json:"developers"`
+}
+
+type ReportRequest struct {
+	From   *time.Time `form:"from"` // DefaultReportPeriod before To by default
+	To     *time.Time `form:"to"`   // now by default
+	Format string     `form:"format"`
+}
+
+type DailyFlatsResponse struct {
+	Days []DailyFlats `json:"days"`
+}
+
+type ModeratorsReportResponse struct {
+	Moderators []ModeratorThroughput `json:"moderators"`
+}
+
+type DeveloperPricesResponse struct {
+	Prices []DeveloperPrice `json:"prices"`
+}
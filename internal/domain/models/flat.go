@@ -1,12 +1,48 @@
 package models
 
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type Flat struct {
-	ID      int `json:"id"`
-	HouseID int `json:"house_id"`
-	//Number  int        `json:"number"`
-	Price  int        `json:"price"`
-	Room   int        `json:"room"`
-	Status FlatStatus `json:"status"`
+	ID         int        `json:"id"`
+	HouseID    int        `json:"house_id"`
+	Number     int        `json:"number,omitempty"` // number of the flat in the house
+	Floor      *int       `json:"floor,omitempty"`  // 0 and negative values are basement floors
+	TotalArea  float64    `json:"total_area,omitempty"`
+	LivingArea float64    `json:"living_area,omitempty"`
+	Price      int        `json:"price"`
+	Room       int        `json:"room"`
+	Status     FlatStatus `json:"status"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	CreatedBy           uuid.UUID  `json:"-"`
+	ModeratorID         uuid.UUID  `json:"-"`
+	ModerationStartedAt *time.Time `json:"-"`
+	PreviousStatus      FlatStatus `json:"-"` // status before the flat is taken on moderation
+
+	Moderation *ModerationEvent `json:"moderation,omitempty"` // result of the last moderation, only for moderators and creator of the flat
+}
+
+type ModerationEvent struct {
+	FlatID        int        `json:"-"`
+	ModeratorID   uuid.UUID  `json:"-"`
+	Status        FlatStatus `json:"status"`
+	DeclineReason string     `json:"decline_reason,omitempty"`
+	Comment       string     `json:"comment,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type PriceChange struct {
+	FlatID    int        `json:"-"`
+	OldPrice  int        `json:"old_price"`
+	NewPrice  int        `json:"new_price"`
+	ChangedBy *uuid.UUID `json:"changed_by,omitempty"` // empty if price is changed by dummy user
+	ChangedAt time.Time  `json:"changed_at"`
 }
 
 type FlatStatus = string
@@ -16,4 +52,27 @@ const (
 	Approved     FlatStatus = "approved"
 	Declined     FlatStatus = "declined"
 	OnModeration FlatStatus = "on moderation"
+	Withdrawn    FlatStatus = "withdrawn"
+)
+
+const (
+	FlatSortID        = "id"
+	FlatSortPrice     = "price"
+	FlatSortRoom      = "room"
+	FlatSortCreatedAt = "created_at"
 )
+
+var FlatSortFields = []string{FlatSortID, FlatSortPrice, FlatSortRoom, FlatSortCreatedAt}
+
+// SortKey returns value of the field used for sorting in string representation
+func (f Flat) SortKey(field string) string {
+	switch field {
+	case FlatSortPrice:
+		return strconv.Itoa(f.Price)
+	case FlatSortRoom:
+		return strconv.Itoa(f.Room)
+	case FlatSortCreatedAt:
+		return f.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return strconv.Itoa(f.ID)
+}
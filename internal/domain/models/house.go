@@ -1,12 +1,76 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type House struct {
 	ID        int       `json:"id"`
 	Address   string    `json:"address"`
 	Year      int       `json:"year"`
-	Developer string    `json:"developer,omitempty"`
+	Developer string    `json:"developer,omitempty"` // name of the developer
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	DeveloperID  *int         `json:"developer_id,omitempty"`
+	Floors       int          `json:"floors,omitempty"`
+	BuildingType BuildingType `json:"building_type,omitempty"`
+	Latitude     *float64     `json:"latitude,omitempty"`
+	Longitude    *float64     `json:"longitude,omitempty"`
+	Amenities    []Amenity    `json:"amenities"`
+
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	AddressNormalized string `json:"-"` // canonical form of address used to detect duplicates
+}
+
+type BuildingType = string
+
+const (
+	Brick         BuildingType = "brick"
+	Panel         BuildingType = "panel"
+	Monolith      BuildingType = "monolith"
+	MonolithBrick BuildingType = "monolith-brick"
+	Block         BuildingType = "block"
+	Wood          BuildingType = "wood"
+)
+
+var BuildingTypes = []BuildingType{Brick, Panel, Monolith, MonolithBrick, Block, Wood}
+
+type Amenity = string
+
+const (
+	Parking      Amenity = "parking"
+	Elevator     Amenity = "elevator"
+	Concierge    Amenity = "concierge"
+	Playground   Amenity = "playground"
+	Security     Amenity = "security"
+	GarbageChute Amenity = "garbage_chute"
+)
+
+var Amenities = []Amenity{Parking, Elevator, Concierge, Playground, Security, GarbageChute}
+
+const MaxNearbyRadius = 100000 // in meters
+
+const (
+	HouseSortID        = "id"
+	HouseSortYear      = "year"
+	HouseSortCreatedAt = "created_at"
+	HouseSortUpdatedAt = "updated_at"
+)
+
+var HouseSortFields = []string{HouseSortID, HouseSortYear, HouseSortCreatedAt, HouseSortUpdatedAt}
+
+// SortKey returns value of the field used for sorting in string representation
+func (h House) SortKey(field string) string {
+	switch field {
+	case HouseSortYear:
+		return strconv.Itoa(h.Year)
+	case HouseSortCreatedAt:
+		return h.CreatedAt.Format(time.RFC3339Nano)
+	case HouseSortUpdatedAt:
+		return h.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	return strconv.Itoa(h.ID)
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+type SortOrder = string
+
+const (
+	Asc  SortOrder = "asc"
+	Desc SortOrder = "desc"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Cursor points to the last element of a page: value of the sort field and id of the element
+type Cursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("can't decode cursor: %w", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, fmt.Errorf("can't unmarshal cursor: %w", err)
+	}
+	return c, nil
+}
+
+type PageRequest struct {
+	SortBy string    `form:"sort_by"`
+	Order  SortOrder `form:"order"`
+	Cursor string    `form:"cursor"`
+	Limit  int       `form:"limit"`
+
+	After *Cursor `form:"-" json:"-"` // decoded cursor
+}
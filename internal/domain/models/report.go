@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+
+	DefaultReportPeriod = 30 * 24 * time.Hour
+	MaxReportPeriod     = 366 * 24 * time.Hour
+
+	ReportDayLayout = time.DateOnly
+)
+
+var ReportFormats = []string{ReportFormatJSON, ReportFormatCSV}
+
+// DailyFlats counts flats created and moderation decisions made during the day (UTC)
+type DailyFlats struct {
+	Day      string `json:"day"`
+	Created  int    `json:"created"`
+	Approved int    `json:"approved"`
+	Declined int    `json:"declined"`
+}
+
+type ModeratorThroughput struct {
+	ModeratorID *uuid.UUID `json:"moderator_id"` // empty for dummy moderators
+	Approved    int        `json:"approved"`
+	Declined    int        `json:"declined"`
+	Total       int        `json:"total"`
+}
+
+// DeveloperPrice describes median price of approved flats in houses of the developer built in the year
+type DeveloperPrice struct {
+	DeveloperID *int    `json:"developer_id"`
+	Developer   string  `json:"developer"`
+	Year        int     `json:"year"`
+	Flats       int     `json:"flats"`
+	MedianPrice float64 `json:"median_price"`
+}
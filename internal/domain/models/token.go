@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token is a data of issued access token, ID is a value of its jti claim.
+// SessionID is a session (family of refresh tokens) the access token is issued with
+type Token struct {
+	ID        uuid.UUID
+	SessionID uuid.UUID
+	UserID    uuid.UUID
+	UserType  UserType
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// RefreshToken is an opaque token exchanged for a new access/refresh pair, only hash of the token is stored.
+// Tokens rotated from the same login share FamilyID, it is the id of the session
+type RefreshToken struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	UserType  UserType
+	Hash      string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// MaxUserAgentLength is a length user agent of session is truncated to
+const MaxUserAgentLength = 255
+
+// ClientInfo describes a device the user logs in from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is a login of the user on some device. Last seen time is updated on refresh of tokens only,
+// requests with access token don't write to storage, so it's precise up to lifetime of access token
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current"`
+}
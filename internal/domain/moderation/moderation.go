@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownStatus    = errors.New("unknown flat status")
+	ErrClaimedByAnother = errors.New("flat is on moderation by another moderator")
+	ErrNotClaimed       = errors.New("flat is not on moderation")
+	ErrClaimExpired     = errors.New("moderation of flat is expired")
+)
+
+// TransitionError is returned when flat can't be moved from one status to another
+type TransitionError struct {
+	From models.FlatStatus
+	To   models.FlatStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("flat status can't be changed from `%s` to `%s`", e.From, e.To)
+}
+
+// transitions describes legal changes of flat status
+var transitions = map[models.FlatStatus][]models.FlatStatus{
+	models.Created:      {models.OnModeration, models.Withdrawn},
+	models.OnModeration: {models.Approved, models.Declined, models.Created}, // previous status if moderation is expired
+	models.Approved:     {models.OnModeration, models.Withdrawn},
+	models.Declined:     {models.OnModeration, models.Withdrawn, models.Created}, // created if creator resubmits the flat
+	models.Withdrawn:    {},
+}
+
+// Decisions are statuses a moderator can set after moderation
+var Decisions = []models.FlatStatus{models.Approved, models.Declined}
+
+func Known(status models.FlatStatus) bool {
+	_, found := transitions[status]
+	return found
+}
+
+func IsDecision(status models.FlatStatus) bool {
+	return slices.Contains(Decisions, status)
+}
+
+// Transit checks if flat can be moved from one status to another
+func Transit(from, to models.FlatStatus) error {
+	if !Known(from) || !Known(to) {
+		return ErrUnknownStatus
+	}
+	if !slices.Contains(transitions[from], to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// Sources returns statuses from which flat can be moved to the given one
+func Sources(to models.FlatStatus) []models.FlatStatus {
+	var sources []models.FlatStatus
+	for from, targets := range transitions {
+		if slices.Contains(targets, to) {
+			sources = append(sources, from)
+		}
+	}
+	slices.Sort(sources)
+	return sources
+}
+
+// Expired reports whether moderation started at the given time is stale
+func Expired(startedAt *time.Time, now time.Time, timeout time.Duration) bool {
+	return startedAt == nil || startedAt.Add(timeout).Before(now)
+}
+
+// CheckStart checks if moderator can take the flat on moderation
+func CheckStart(flat models.Flat, moderator uuid.UUID, now time.Time, timeout time.Duration) error {
+	if flat.Status == models.OnModeration {
+		if flat.ModeratorID == moderator || Expired(flat.ModerationStartedAt, now, timeout) {
+			return nil
+		}
+		return ErrClaimedByAnother
+	}
+	return Transit(flat.Status, models.OnModeration)
+}
+
+// CheckResubmit checks if creator can send the flat to moderation again
+func CheckResubmit(flat models.Flat) error {
+	if flat.Status != models.Declined {
+		return &TransitionError{From: flat.Status, To: models.Created}
+	}
+	return nil
+}
+
+// CheckFinish checks if moderator can set the final status of the flat
+func CheckFinish(flat models.Flat, moderator uuid.UUID, status models.FlatStatus, now time.Time, timeout time.Duration) error {
+	if flat.Status != models.OnModeration {
+		return ErrNotClaimed
+	}
+	if flat.ModeratorID != moderator {
+		return ErrClaimedByAnother
+	}
+	if Expired(flat.ModerationStartedAt, now, timeout) {
+		return ErrClaimExpired
+	}
+	return Transit(flat.Status, status)
+}
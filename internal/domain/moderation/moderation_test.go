@@ -0,0 +1,66 @@
+package moderation_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/domain/moderation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit(t *testing.T) {
+	tests := []struct {
+		from, to models.FlatStatus
+		legal    bool
+	}{
+		{models.Created, models.OnModeration, true},
+		{models.Created, models.Approved, false},
+		{models.OnModeration, models.Approved, true},
+		{models.OnModeration, models.Declined, true},
+		{models.OnModeration, models.OnModeration, false},
+		{models.OnModeration, models.Created, true},
+		{models.Approved, models.Created, false},
+		{models.Declined, models.OnModeration, true},
+		{models.Declined, models.Created, true},
+		{models.Withdrawn, models.OnModeration, false},
+	}
+
+	for _, test := range tests {
+		err := moderation.Transit(test.from, test.to)
+		if test.legal {
+			require.NoErrorf(t, err, "transition from %s to %s should be legal", test.from, test.to)
+			continue
+		}
+		var transitionErr *moderation.TransitionError
+		require.Truef(t, errors.As(err, &transitionErr), "transition from %s to %s should be illegal", test.from, test.to)
+	}
+}
+
+func TestTransitUnknownStatus(t *testing.T) {
+	require.ErrorIs(t, moderation.Transit(models.Created, "garbage"), moderation.ErrUnknownStatus)
+}
+
+func TestSources(t *testing.T) {
+	require.Equal(t, []models.FlatStatus{models.OnModeration}, moderation.Sources(models.Approved))
+}
+
+func TestClaim(t *testing.T) {
+	now, timeout := time.Now(), 10*time.Minute
+	first, second := uuid.New(), uuid.New()
+	started := now.Add(-time.Minute)
+	flat := models.Flat{Status: models.OnModeration, ModeratorID: first, ModerationStartedAt: &started}
+
+	require.NoError(t, moderation.CheckStart(flat, first, now, timeout))
+	require.ErrorIs(t, moderation.CheckStart(flat, second, now, timeout), moderation.ErrClaimedByAnother)
+	require.NoError(t, moderation.CheckStart(flat, second, now.Add(timeout), timeout))
+
+	require.NoError(t, moderation.CheckFinish(flat, first, models.Approved, now, timeout))
+	require.ErrorIs(t, moderation.CheckFinish(flat, second, models.Approved, now, timeout), moderation.ErrClaimedByAnother)
+	require.ErrorIs(t, moderation.CheckFinish(flat, first, models.Approved, now.Add(timeout), timeout), moderation.ErrClaimExpired)
+
+	flat.Status = models.Created
+	require.ErrorIs(t, moderation.CheckFinish(flat, first, models.Approved, now, timeout), moderation.ErrNotClaimed)
+}
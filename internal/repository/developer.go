@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+)
+
+type DeveloperStorage interface {
+	Create(context.Context, models.DeveloperCreateRequest) (models.Developer, DatabaseError)
+	Get(context.Context, int) (models.Developer, DatabaseError)
+	GetByName(context.Context, string) (models.Developer, DatabaseError) // case insensitive
+	List(context.Context) ([]models.Developer, DatabaseError)
+	Update(context.Context, models.DeveloperUpdateRequest) (models.Developer, DatabaseError) // also renames developer of its houses
+	Delete(context.Context, int) DatabaseError                                               // fails with ErrEntityInUse if developer has houses
+}
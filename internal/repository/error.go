@@ -6,12 +6,16 @@ const (
 	msgNoRowsAffected = "no rows affected"
 	msgEntityNotFound = "no entity found"
 	msgAlreadyExists  = "entity already exists"
+	msgArchived       = "entity is archived"
+	msgInUse          = "entity is in use"
 )
 
 var (
 	ErrNoRowsAffected      = fmt.Errorf(msgNoRowsAffected)
 	ErrEntityNotFound      = fmt.Errorf(msgEntityNotFound)
 	ErrEntityAlreadyExists = fmt.Errorf(msgAlreadyExists)
+	ErrEntityArchived      = fmt.Errorf(msgArchived)
+	ErrEntityInUse         = fmt.Errorf(msgInUse)
 )
 
 type DatabaseError interface {
@@ -2,12 +2,30 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/google/uuid"
 )
 
+// FlatBatchResult is outcome of creation of one flat of the batch, Err is set if the flat is rejected
+type FlatBatchResult struct {
+	Flat models.Flat
+	Err  error
+}
+
 type FlatStorage interface {
 	Create(context.Context, models.FlatCreateRequest) (models.FlatCreateResponse, DatabaseError)
-	Update(context.Context, models.FlatUpdateRequest) (models.FlatUpdateResponse, DatabaseError)
+	CreateBatch(ctx context.Context, houseID int, reqs []models.FlatCreateRequest) ([]FlatBatchResult, DatabaseError) // in a single transaction, fails entirely if the house is archived or not found
 	Get(context.Context, models.Flat) (models.Flat, DatabaseError)
+	List(context.Context, models.FlatListRequest, models.User) ([]models.Flat, DatabaseError) // returns up to limit+1 flats to detect the next page
+	Withdraw(context.Context, int) (models.Flat, DatabaseError)
+	StartModeration(ctx context.Context, id int, moderator uuid.UUID, timeout time.Duration) (models.Flat, DatabaseError)
+	FinishModeration(ctx context.Context, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.FlatUpdateResponse, DatabaseError)
+	Moderate(ctx context.Context, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.FlatUpdateResponse, DatabaseError) // claims and finishes moderation at once
+	ReleaseExpired(ctx context.Context, timeout time.Duration) (int, DatabaseError)                                                                    // returns count of released flats
+	Queue(context.Context, models.ModerationQueueRequest) ([]models.Flat, DatabaseError)                                                               // returns up to limit+1 flats to detect the next page
+	Resubmit(context.Context, models.FlatUpdateRequest) (models.Flat, DatabaseError)
+	LastModerationEvents(context.Context, []int) (map[int]models.ModerationEvent, DatabaseError) // key is id of flat
+	PriceHistory(context.Context, int) ([]models.PriceChange, DatabaseError)
 }
@@ -8,5 +8,14 @@ import (
 
 type HouseStorage interface {
 	Create(context.Context, models.HouseCreateRequest) (models.HouseCreateResponse, DatabaseError)
+	Get(context.Context, int) (models.House, DatabaseError)
+	GetByAddress(context.Context, string) (models.House, DatabaseError) // searches not archived house by normalized address
 	Flats(context.Context, models.HouseGetFlatsRequest, models.User) (models.HouseGetFlatsResponse, DatabaseError)
+	List(context.Context, models.HouseListRequest, models.User) ([]models.House, DatabaseError) // returns up to limit+1 houses to detect the next page
+	Update(context.Context, models.HouseUpdateRequest) (models.HouseUpdateResponse, DatabaseError)
+	Nearby(context.Context, models.HouseNearbyRequest, models.User) ([]models.HouseLocation, DatabaseError) // sorted by distance
+	Within(context.Context, models.HouseWithinRequest, models.User) ([]models.HouseLocation, DatabaseError)
+	Stats(context.Context, int, models.User) (models.HouseStatsResponse, DatabaseError)                         // clients get statistics of approved flats only
+	Search(context.Context, models.HouseSearchRequest, models.User) ([]models.HouseSearchResult, DatabaseError) // full-text search by address and developer
+	Archive(context.Context, int) (models.House, DatabaseError)
 }
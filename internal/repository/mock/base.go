@@ -1,21 +1,45 @@
 package mock
 
 import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/address"
+	"github.com/antsrp/house_service/internal/domain/geo"
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/domain/moderation"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
 )
 
 type Base struct {
-	flats     map[int]models.Flat
-	houses    map[int]models.House
-	cntFlats  int
-	cntHouses int
+	flats         map[int]models.Flat
+	houses        map[int]models.House
+	developers    map[int]models.Developer
+	events        map[int][]models.ModerationEvent // moderation events by id of flat
+	prices        map[int][]models.PriceChange     // price changes by id of flat
+	revoked       map[uuid.UUID]time.Time          // expiration time of revoked tokens by jti
+	refreshTokens map[uuid.UUID]models.RefreshToken
+	userRevoked   map[uuid.UUID]time.Time // time all tokens of user issued before are revoked
+	sessions      map[uuid.UUID]models.Session
+	cntFlats      int
+	cntHouses     int
+	cntDevelopers int
 }
 
 func NewBase() Base {
 	return Base{
-		flats:  make(map[int]models.Flat),
-		houses: make(map[int]models.House),
+		flats:         make(map[int]models.Flat),
+		houses:        make(map[int]models.House),
+		developers:    make(map[int]models.Developer),
+		events:        make(map[int][]models.ModerationEvent),
+		prices:        make(map[int][]models.PriceChange),
+		revoked:       make(map[uuid.UUID]time.Time),
+		refreshTokens: make(map[uuid.UUID]models.RefreshToken),
+		userRevoked:   make(map[uuid.UUID]time.Time),
+		sessions:      make(map[uuid.UUID]models.Session),
 	}
 }
 
@@ -28,14 +52,28 @@ func (b *Base) AddHouse(house models.House) int {
 func (b *Base) AddFlat(flat models.Flat) int {
 	b.flats[b.cntFlats+1] = flat
 	b.cntFlats++
+	b.touchHouse(flat.HouseID)
 	return b.cntFlats
 }
 
+// touchHouse changes update time of the house like update_house trigger does
+func (b Base) touchHouse(id int) {
+	house, found := b.houses[id]
+	if !found {
+		return
+	}
+	house.UpdatedAt = time.Now()
+	b.houses[id] = house
+}
+
 func (b Base) Flats(req models.HouseGetFlatsRequest, user models.User) (models.HouseGetFlatsResponse, repository.DatabaseError) {
 	house, err := b.GetHouse(req.ID)
 	if err != nil {
 		return models.HouseGetFlatsResponse{}, NewMockError(false, err)
 	}
+	if user.UserType == models.Client && house.ArchivedAt != nil {
+		return models.HouseGetFlatsResponse{}, NewMockError(false, repository.ErrEntityNotFound)
+	}
 
 	var flats []models.Flat
 
@@ -48,6 +86,7 @@ func (b Base) Flats(req models.HouseGetFlatsRequest, user models.User) (models.H
 			flats = append(flats, v)
 		}
 	}
+	sort.Slice(flats, func(i, j int) bool { return flats[i].ID < flats[j].ID })
 
 	return models.HouseGetFlatsResponse{
 		House: house,
@@ -55,6 +94,57 @@ func (b Base) Flats(req models.HouseGetFlatsRequest, user models.User) (models.H
 	}, nil
 }
 
+// median interpolates middle value like percentile_cont does, values should not be empty
+func median(values []int) float64 {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	middle := len(values) / 2
+	if len(values)%2 == 1 {
+		return float64(values[middle])
+	}
+	return float64(values[middle-1]+values[middle]) / 2
+}
+
+// HouseStats computes statistics of flats of the house
+func (b Base) HouseStats(id int, user models.User) (models.HouseStatsResponse, error) {
+	house, err := b.GetHouse(id)
+	if err != nil {
+		return models.HouseStatsResponse{}, err
+	}
+	if user.UserType == models.Client && house.ArchivedAt != nil {
+		return models.HouseStatsResponse{}, repository.ErrEntityNotFound
+	}
+
+	stats := models.HouseStatsResponse{
+		HouseID:     id,
+		Statuses:    make(map[models.FlatStatus]int),
+		Prices:      []models.RoomPriceStats{},
+		UpdatedAt:   house.UpdatedAt,
+		SinceUpdate: int64(time.Since(house.UpdatedAt).Seconds()),
+	}
+	prices := make(map[int][]int) // by count of rooms
+	for _, flat := range b.flats {
+		if flat.HouseID != id || (user.UserType == models.Client && flat.Status != models.Approved) {
+			continue
+		}
+		stats.Statuses[flat.Status]++
+		prices[flat.Room] = append(prices[flat.Room], flat.Price)
+	}
+	for rooms, values := range prices {
+		price := models.RoomPriceStats{Rooms: rooms, Count: len(values), Min: slices.Min(values), Max: slices.Max(values)}
+		sum := 0
+		for _, value := range values {
+			sum += value
+		}
+		price.Average = float64(sum) / float64(len(values))
+		price.Median = median(values)
+		stats.Prices = append(stats.Prices, price)
+	}
+	sort.Slice(stats.Prices, func(i, j int) bool { return stats.Prices[i].Rooms < stats.Prices[j].Rooms })
+
+	return stats, nil
+}
+
 func (b Base) GetHouse(id int) (models.House, error) {
 	house, found := b.houses[id]
 	if !found {
@@ -65,6 +155,21 @@ func (b Base) GetHouse(id int) (models.House, error) {
 	return house, nil
 }
 
+// HouseByAddress returns not archived house with the lowest id by normalized address
+func (b Base) HouseByAddress(normalized string) (models.House, error) {
+	found := models.House{}
+	for id, house := range b.houses {
+		if house.AddressNormalized == normalized && house.ArchivedAt == nil && (found.ID == 0 || id < found.ID) {
+			house.ID = id
+			found = house
+		}
+	}
+	if found.ID == 0 {
+		return models.House{}, repository.ErrEntityNotFound
+	}
+	return found, nil
+}
+
 func (b Base) GetFlat(id int) (models.Flat, error) {
 	flat, found := b.flats[id]
 	if !found {
@@ -80,6 +185,363 @@ func (b Base) UpdateFlat(flat models.Flat) error {
 		return repository.ErrEntityNotFound
 	}
 	b.flats[flat.ID] = flat
+	b.touchHouse(flat.HouseID)
 
 	return nil
 }
+
+// NumberTaken reports whether the number is used by another flat of the house
+func (b Base) NumberTaken(houseID, number, exceptID int) bool {
+	if number == 0 {
+		return false
+	}
+	for id, flat := range b.flats {
+		if id != exceptID && flat.HouseID == houseID && flat.Number == number {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Base) AddModerationEvent(event models.ModerationEvent) {
+	b.events[event.FlatID] = append(b.events[event.FlatID], event)
+}
+
+func (b Base) AddPriceChange(change models.PriceChange) {
+	b.prices[change.FlatID] = append(b.prices[change.FlatID], change)
+}
+
+func (b Base) PriceHistory(id int) []models.PriceChange {
+	return slices.Clone(b.prices[id])
+}
+
+func (b Base) LastModerationEvents(ids []int) map[int]models.ModerationEvent {
+	events := make(map[int]models.ModerationEvent, len(ids))
+	for _, id := range ids {
+		if list := b.events[id]; len(list) > 0 {
+			events[id] = list[len(list)-1]
+		}
+	}
+	return events
+}
+
+func (b Base) UpdateHouse(req models.HouseUpdateRequest) (models.House, error) {
+	house, err := b.GetHouse(req.ID)
+	if err != nil {
+		return models.House{}, err
+	}
+	if req.Address != nil {
+		house.Address = *req.Address
+	}
+	if req.Year != nil {
+		house.Year = *req.Year
+	}
+	if req.Developer != nil {
+		house.Developer = *req.Developer
+	}
+	if req.DeveloperID != nil {
+		house.DeveloperID = req.DeveloperID
+	}
+	if req.AddressNormalized != nil {
+		house.AddressNormalized = *req.AddressNormalized
+	}
+	if req.Floors != nil {
+		house.Floors = *req.Floors
+	}
+	if req.BuildingType != nil {
+		house.BuildingType = *req.BuildingType
+	}
+	if req.Latitude != nil {
+		house.Latitude = req.Latitude
+	}
+	if req.Longitude != nil {
+		house.Longitude = req.Longitude
+	}
+	if req.Amenities != nil {
+		house.Amenities = req.Amenities
+	}
+	house.UpdatedAt = time.Now()
+	b.houses[house.ID] = house
+
+	return house, nil
+}
+
+func (b Base) ArchiveHouse(id int) (models.House, error) {
+	house, err := b.GetHouse(id)
+	if err != nil {
+		return models.House{}, err
+	}
+	if house.ArchivedAt != nil {
+		return models.House{}, repository.ErrEntityArchived
+	}
+	now := time.Now()
+	house.ArchivedAt = &now
+	b.houses[id] = house
+
+	return house, nil
+}
+
+func (b Base) Houses(req models.HouseListRequest, user models.User) []models.House {
+	var houses []models.House
+	for k, v := range b.houses {
+		v.ID = k
+		if user.UserType == models.Client && v.ArchivedAt != nil {
+			continue
+		}
+		if req.Address != nil && !strings.Contains(strings.ToLower(v.Address), strings.ToLower(*req.Address)) {
+			continue
+		}
+		if req.Developer != nil && !strings.EqualFold(v.Developer, *req.Developer) {
+			continue
+		}
+		if req.DeveloperID != nil && (v.DeveloperID == nil || *v.DeveloperID != *req.DeveloperID) {
+			continue
+		}
+		if req.YearFrom != nil && v.Year < *req.YearFrom {
+			continue
+		}
+		if req.YearTo != nil && v.Year > *req.YearTo {
+			continue
+		}
+		if req.CreatedFrom != nil && v.CreatedAt.Before(*req.CreatedFrom) {
+			continue
+		}
+		if req.CreatedTo != nil && v.CreatedAt.After(*req.CreatedTo) {
+			continue
+		}
+		if req.UpdatedFrom != nil && v.UpdatedAt.Before(*req.UpdatedFrom) {
+			continue
+		}
+		if req.UpdatedTo != nil && v.UpdatedAt.After(*req.UpdatedTo) {
+			continue
+		}
+		houses = append(houses, v)
+	}
+
+	return paginate(houses, func(h models.House) models.Cursor {
+		return models.Cursor{Value: h.SortKey(req.SortBy), ID: h.ID}
+	}, req.PageRequest)
+}
+
+func (b Base) ListFlats(req models.FlatListRequest, user models.User) []models.Flat {
+	var flats []models.Flat
+	for k, v := range b.flats {
+		v.ID = k
+		if req.HouseID != nil && v.HouseID != *req.HouseID {
+			continue
+		}
+		if req.PriceFrom != nil && v.Price < *req.PriceFrom {
+			continue
+		}
+		if req.PriceTo != nil && v.Price > *req.PriceTo {
+			continue
+		}
+		if req.Room != nil && v.Room != *req.Room {
+			continue
+		}
+		if req.CreatedBy != nil && v.CreatedBy != *req.CreatedBy {
+			continue
+		}
+		own := req.CreatedBy != nil
+		if user.UserType == models.Client && !own && (v.Status != models.Approved || b.houses[v.HouseID].ArchivedAt != nil) {
+			continue
+		}
+		if (user.UserType != models.Client || own) && req.Status != nil && v.Status != *req.Status {
+			continue
+		}
+		flats = append(flats, v)
+	}
+
+	return paginate(flats, func(f models.Flat) models.Cursor {
+		return models.Cursor{Value: f.SortKey(req.SortBy), ID: f.ID}
+	}, req.PageRequest)
+}
+
+func (b Base) ReleaseExpired(timeout time.Duration) int {
+	var cnt int
+	now := time.Now()
+	for k, v := range b.flats {
+		if v.Status != models.OnModeration || !moderation.Expired(v.ModerationStartedAt, now, timeout) {
+			continue
+		}
+		v.Status, v.ModeratorID, v.ModerationStartedAt = models.Created, uuid.Nil, nil
+		if v.PreviousStatus != "" {
+			v.Status, v.PreviousStatus = v.PreviousStatus, ""
+		}
+		b.flats[k] = v
+		b.touchHouse(v.HouseID)
+		cnt++
+	}
+	return cnt
+}
+
+func (b Base) Queue(req models.ModerationQueueRequest) []models.Flat {
+	var flats []models.Flat
+	for k, v := range b.flats {
+		v.ID = k
+		house := b.houses[v.HouseID]
+		if v.Status != models.Created || house.ArchivedAt != nil {
+			continue
+		}
+		if req.HouseID != nil && v.HouseID != *req.HouseID {
+			continue
+		}
+		if req.Developer != nil && !strings.EqualFold(house.Developer, *req.Developer) {
+			continue
+		}
+		flats = append(flats, v)
+	}
+
+	return paginate(flats, func(f models.Flat) models.Cursor {
+		return models.Cursor{Value: f.SortKey(req.SortBy), ID: f.ID}
+	}, req.PageRequest)
+}
+
+// locate returns visible for the user houses with coordinates which are accepted by the function, it also may return distance to the house
+func (b Base) locate(user models.User, accept func(geo.Point) (*float64, bool)) []models.HouseLocation {
+	var houses []models.HouseLocation
+	for id, house := range b.houses {
+		house.ID = id
+		if house.Latitude == nil || house.Longitude == nil || (user.UserType == models.Client && house.ArchivedAt != nil) {
+			continue
+		}
+		distance, ok := accept(geo.Point{Latitude: *house.Latitude, Longitude: *house.Longitude})
+		if !ok {
+			continue
+		}
+		location := models.HouseLocation{House: house, Distance: distance}
+		for _, flat := range b.flats {
+			if flat.HouseID == id && flat.Status == models.Approved {
+				location.ApprovedFlats++
+			}
+		}
+		houses = append(houses, location)
+	}
+	return houses
+}
+
+func (b Base) Nearby(req models.HouseNearbyRequest, user models.User) []models.HouseLocation {
+	center := geo.Point{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	houses := b.locate(user, func(p geo.Point) (*float64, bool) {
+		distance := geo.Distance(center, p)
+		return &distance, distance <= *req.Radius
+	})
+	sort.Slice(houses, func(i, j int) bool {
+		if *houses[i].Distance != *houses[j].Distance {
+			return *houses[i].Distance < *houses[j].Distance
+		}
+		return houses[i].ID < houses[j].ID
+	})
+	return houses[:min(len(houses), req.Limit)]
+}
+
+func (b Base) Within(req models.HouseWithinRequest, user models.User) []models.HouseLocation {
+	box := geo.Box{MinLatitude: *req.MinLatitude, MinLongitude: *req.MinLongitude, MaxLatitude: *req.MaxLatitude, MaxLongitude: *req.MaxLongitude}
+	houses := b.locate(user, func(p geo.Point) (*float64, bool) {
+		return nil, box.Contains(p)
+	})
+	sort.Slice(houses, func(i, j int) bool { return houses[i].ID < houses[j].ID })
+	return houses[:min(len(houses), req.Limit)]
+}
+
+// SearchHouses is a simple replacement of full-text search: every word of the query should be a prefix of some word
+// of address or developer, exact matches are ranked higher
+func (b Base) SearchHouses(req models.HouseSearchRequest, user models.User) []models.HouseSearchResult {
+	query := address.Tokens(req.Query)
+	var houses []models.HouseSearchResult
+	for id, house := range b.houses {
+		house.ID = id
+		if user.UserType == models.Client && house.ArchivedAt != nil {
+			continue
+		}
+		words := address.Tokens(house.Address + " " + house.Developer)
+		result := models.HouseSearchResult{House: house}
+		for _, token := range query {
+			idx := slices.IndexFunc(words, func(word string) bool { return strings.HasPrefix(word, token) })
+			if idx == -1 {
+				result.Rank = 0
+				break
+			}
+			result.Rank += float64(len(token)) / float64(len(words[idx]))
+		}
+		if result.Rank > 0 {
+			houses = append(houses, result)
+		}
+	}
+	sort.Slice(houses, func(i, j int) bool {
+		if houses[i].Rank != houses[j].Rank {
+			return houses[i].Rank > houses[j].Rank
+		}
+		return houses[i].ID < houses[j].ID
+	})
+	return houses[:min(len(houses), req.Limit)]
+}
+
+func (b *Base) AddDeveloper(developer models.Developer) int {
+	b.developers[b.cntDevelopers+1] = developer
+	b.cntDevelopers++
+	return b.cntDevelopers
+}
+
+func (b Base) GetDeveloper(id int) (models.Developer, error) {
+	developer, found := b.developers[id]
+	if !found {
+		return models.Developer{}, repository.ErrEntityNotFound
+	}
+	developer.ID = id
+
+	return developer, nil
+}
+
+func (b Base) DeveloperByName(name string) (models.Developer, error) {
+	for id, developer := range b.developers {
+		if strings.EqualFold(developer.Name, strings.TrimSpace(name)) {
+			developer.ID = id
+			return developer, nil
+		}
+	}
+	return models.Developer{}, repository.ErrEntityNotFound
+}
+
+func (b Base) Developers() []models.Developer {
+	var developers []models.Developer
+	for id, developer := range b.developers {
+		developer.ID = id
+		developers = append(developers, developer)
+	}
+	sort.Slice(developers, func(i, j int) bool {
+		if developers[i].Name != developers[j].Name {
+			return developers[i].Name < developers[j].Name
+		}
+		return developers[i].ID < developers[j].ID
+	})
+	return developers
+}
+
+// UpdateDeveloper changes developer and renames developer of its houses
+func (b Base) UpdateDeveloper(developer models.Developer) error {
+	if _, found := b.developers[developer.ID]; !found {
+		return repository.ErrEntityNotFound
+	}
+	b.developers[developer.ID] = developer
+	for id, house := range b.houses {
+		if house.DeveloperID != nil && *house.DeveloperID == developer.ID && house.Developer != developer.Name {
+			house.Developer, house.UpdatedAt = developer.Name, time.Now()
+			b.houses[id] = house
+		}
+	}
+	return nil
+}
+
+func (b Base) DeleteDeveloper(id int) error {
+	if _, found := b.developers[id]; !found {
+		return repository.ErrEntityNotFound
+	}
+	for _, house := range b.houses {
+		if house.DeveloperID != nil && *house.DeveloperID == id {
+			return repository.ErrEntityInUse
+		}
+	}
+	delete(b.developers, id)
+	return nil
+}
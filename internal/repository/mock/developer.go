@@ -0,0 +1,82 @@
+package mock
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+)
+
+type DeveloperStorage struct {
+	base *Base
+}
+
+var _ repository.DeveloperStorage = DeveloperStorage{}
+
+func NewDeveloperStorage(base *Base) DeveloperStorage {
+	return DeveloperStorage{
+		base: base,
+	}
+}
+
+func (d DeveloperStorage) Create(ctx context.Context, req models.DeveloperCreateRequest) (models.Developer, repository.DatabaseError) {
+	if _, err := d.base.DeveloperByName(req.Name); err == nil {
+		return models.Developer{}, NewMockError(false, repository.ErrEntityAlreadyExists)
+	}
+	developer := models.Developer{
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	developer.ID = d.base.AddDeveloper(developer)
+
+	return developer, nil
+}
+
+func (d DeveloperStorage) Get(ctx context.Context, id int) (models.Developer, repository.DatabaseError) {
+	developer, err := d.base.GetDeveloper(id)
+	if err != nil {
+		return models.Developer{}, NewMockError(false, err)
+	}
+	return developer, nil
+}
+
+func (d DeveloperStorage) GetByName(ctx context.Context, name string) (models.Developer, repository.DatabaseError) {
+	developer, err := d.base.DeveloperByName(name)
+	if err != nil {
+		return models.Developer{}, NewMockError(false, err)
+	}
+	return developer, nil
+}
+
+func (d DeveloperStorage) List(ctx context.Context) ([]models.Developer, repository.DatabaseError) {
+	return d.base.Developers(), nil
+}
+
+func (d DeveloperStorage) Update(ctx context.Context, req models.DeveloperUpdateRequest) (models.Developer, repository.DatabaseError) {
+	developer, err := d.base.GetDeveloper(req.ID)
+	if err != nil {
+		return models.Developer{}, NewMockError(false, err)
+	}
+	if req.Name != nil {
+		if other, err := d.base.DeveloperByName(*req.Name); err == nil && other.ID != req.ID {
+			return models.Developer{}, NewMockError(false, repository.ErrEntityAlreadyExists)
+		}
+		developer.Name = strings.TrimSpace(*req.Name)
+	}
+	developer.UpdatedAt = time.Now()
+	if err := d.base.UpdateDeveloper(developer); err != nil {
+		return models.Developer{}, NewMockError(false, err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperStorage) Delete(ctx context.Context, id int) repository.DatabaseError {
+	if err := d.base.DeleteDeveloper(id); err != nil {
+		return NewMockError(false, err)
+	}
+	return nil
+}
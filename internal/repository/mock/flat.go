@@ -2,9 +2,12 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/domain/moderation"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
 )
 
 type FlatStorage struct {
@@ -20,14 +23,35 @@ func NewFlatStorage(base *Base) FlatStorage {
 }
 
 func (f FlatStorage) Create(ctx context.Context, req models.FlatCreateRequest) (models.FlatCreateResponse, repository.DatabaseError) {
-	if _, err := f.base.GetHouse(req.HouseID); err != nil {
+	house, err := f.base.GetHouse(req.HouseID)
+	if err != nil {
 		return models.FlatCreateResponse{}, NewMockError(false, err)
 	}
+	if house.ArchivedAt != nil {
+		return models.FlatCreateResponse{}, NewMockError(false, repository.ErrEntityArchived)
+	}
+	if f.base.NumberTaken(req.HouseID, req.Number, 0) {
+		return models.FlatCreateResponse{}, NewMockError(false, repository.ErrEntityAlreadyExists)
+	}
 	flat := models.Flat{
 		HouseID: req.HouseID,
+		Number:  req.Number,
 		Price:   *req.Price,
 		Room:    req.Room,
 		Status:  models.Created,
+
+		CreatedAt: time.Now(),
+		CreatedBy: req.CreatedBy,
+	}
+	if req.Floor != nil {
+		floor := *req.Floor
+		flat.Floor = &floor
+	}
+	if req.TotalArea != nil {
+		flat.TotalArea = *req.TotalArea
+	}
+	if req.LivingArea != nil {
+		flat.LivingArea = *req.LivingArea
 	}
 	id := f.base.AddFlat(flat)
 	flat.ID = id
@@ -37,6 +61,29 @@ func (f FlatStorage) Create(ctx context.Context, req models.FlatCreateRequest) (
 	}, nil
 }
 
+func (f FlatStorage) CreateBatch(ctx context.Context, houseID int, reqs []models.FlatCreateRequest) ([]repository.FlatBatchResult, repository.DatabaseError) {
+	house, err := f.base.GetHouse(houseID)
+	if err != nil {
+		return nil, NewMockError(false, err)
+	}
+	if house.ArchivedAt != nil {
+		return nil, NewMockError(false, repository.ErrEntityArchived)
+	}
+
+	results := make([]repository.FlatBatchResult, len(reqs))
+	for i, req := range reqs {
+		req.HouseID = houseID
+		resp, err := f.Create(ctx, req)
+		if err != nil {
+			results[i].Err = err.Cause()
+			continue
+		}
+		results[i].Flat = resp.Flat
+	}
+
+	return results, nil
+}
+
 func (f FlatStorage) Get(ctx context.Context, req models.Flat) (models.Flat, repository.DatabaseError) {
 	flat, err := f.base.GetFlat(req.ID)
 	if err != nil {
@@ -45,20 +92,161 @@ func (f FlatStorage) Get(ctx context.Context, req models.Flat) (models.Flat, rep
 	return flat, nil
 }
 
-func (f FlatStorage) Update(ctx context.Context, req models.FlatUpdateRequest) (models.FlatUpdateResponse, repository.DatabaseError) {
-	flat := models.Flat{
-		ID:    req.ID,
-		Price: *req.Price,
-		Room:  req.Room,
+// applyAttributes changes attributes of the flat which are passed in the request, change of the price is saved to history
+func (f FlatStorage) applyAttributes(flat *models.Flat, req models.FlatUpdateRequest, changedBy uuid.UUID) error {
+	if req.Number != nil {
+		if f.base.NumberTaken(flat.HouseID, *req.Number, flat.ID) {
+			return repository.ErrEntityAlreadyExists
+		}
+		flat.Number = *req.Number
+	}
+	if req.Floor != nil {
+		floor := *req.Floor
+		flat.Floor = &floor
+	}
+	if req.TotalArea != nil {
+		flat.TotalArea = *req.TotalArea
+	}
+	if req.LivingArea != nil {
+		flat.LivingArea = *req.LivingArea
+	}
+	if req.Price != nil && *req.Price != flat.Price {
+		change := models.PriceChange{FlatID: flat.ID, OldPrice: flat.Price, NewPrice: *req.Price, ChangedAt: time.Now()}
+		if changedBy != uuid.Nil {
+			change.ChangedBy = &changedBy
+		}
+		f.base.AddPriceChange(change)
+		flat.Price = *req.Price
+	}
+	if req.Room > 0 {
+		flat.Room = req.Room
+	}
+	return nil
+}
+
+func (f FlatStorage) List(ctx context.Context, req models.FlatListRequest, user models.User) ([]models.Flat, repository.DatabaseError) {
+	return f.base.ListFlats(req, user), nil
+}
+
+func (f FlatStorage) Withdraw(ctx context.Context, id int) (models.Flat, repository.DatabaseError) {
+	flat, err := f.base.GetFlat(id)
+	if err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	if err := moderation.Transit(flat.Status, models.Withdrawn); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	flat.Status = models.Withdrawn
+	if err := f.base.UpdateFlat(flat); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+
+	return flat, nil
+}
+
+func (f FlatStorage) Resubmit(ctx context.Context, req models.FlatUpdateRequest) (models.Flat, repository.DatabaseError) {
+	flat, err := f.base.GetFlat(req.ID)
+	if err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	if err := moderation.CheckResubmit(flat); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	flat.Status, flat.ModeratorID, flat.ModerationStartedAt = models.Created, uuid.Nil, nil
+	if err := f.applyAttributes(&flat, req, req.UpdatedBy); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	if err := f.base.UpdateFlat(flat); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+
+	return flat, nil
+}
+
+func (f FlatStorage) StartModeration(ctx context.Context, id int, moderator uuid.UUID, timeout time.Duration) (models.Flat, repository.DatabaseError) {
+	flat, err := f.base.GetFlat(id)
+	if err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	now := time.Now()
+	if err := moderation.CheckStart(flat, moderator, now, timeout); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+	if flat.Status != models.OnModeration {
+		flat.PreviousStatus = flat.Status
+	}
+	flat.Status, flat.ModeratorID, flat.ModerationStartedAt = models.OnModeration, moderator, &now
+	if err := f.base.UpdateFlat(flat); err != nil {
+		return models.Flat{}, NewMockError(false, err)
+	}
+
+	return flat, nil
+}
+
+func (f FlatStorage) FinishModeration(ctx context.Context, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.FlatUpdateResponse, repository.DatabaseError) {
+	flat, err := f.base.GetFlat(req.ID)
+	if err != nil {
+		return models.FlatUpdateResponse{}, NewMockError(false, err)
 	}
-	if req.Status != nil {
-		flat.Status = *req.Status
+	if err := moderation.CheckFinish(flat, moderator, *req.Status, time.Now(), timeout); err != nil {
+		return models.FlatUpdateResponse{}, NewMockError(false, err)
+	}
+	flat.Status, flat.PreviousStatus = *req.Status, ""
+	if err := f.applyAttributes(&flat, req, moderator); err != nil {
+		return models.FlatUpdateResponse{}, NewMockError(false, err)
 	}
 	if err := f.base.UpdateFlat(flat); err != nil {
 		return models.FlatUpdateResponse{}, NewMockError(false, err)
 	}
+	event := models.ModerationEvent{
+		FlatID:      flat.ID,
+		ModeratorID: moderator,
+		Status:      flat.Status,
+		CreatedAt:   time.Now(),
+	}
+	if req.DeclineReason != nil {
+		event.DeclineReason = *req.DeclineReason
+	}
+	if req.Comment != nil {
+		event.Comment = *req.Comment
+	}
+	f.base.AddModerationEvent(event)
+	flat.Moderation = &event
 
 	return models.FlatUpdateResponse{
 		Flat: flat,
 	}, nil
 }
+
+func (f FlatStorage) Moderate(ctx context.Context, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.FlatUpdateResponse, repository.DatabaseError) {
+	flat, err := f.base.GetFlat(req.ID)
+	if err != nil {
+		return models.FlatUpdateResponse{}, NewMockError(false, err)
+	}
+	if _, err := f.StartModeration(ctx, req.ID, moderator, timeout); err != nil {
+		return models.FlatUpdateResponse{}, err
+	}
+	res, dbErr := f.FinishModeration(ctx, req, moderator, timeout)
+	if dbErr != nil { // roll back the claim like the transaction does
+		f.base.UpdateFlat(flat)
+		return models.FlatUpdateResponse{}, dbErr
+	}
+
+	return res, nil
+}
+
+func (f FlatStorage) LastModerationEvents(ctx context.Context, ids []int) (map[int]models.ModerationEvent, repository.DatabaseError) {
+	return f.base.LastModerationEvents(ids), nil
+}
+
+func (f FlatStorage) PriceHistory(ctx context.Context, id int) ([]models.PriceChange, repository.DatabaseError) {
+	return f.base.PriceHistory(id), nil
+}
+
+func (f FlatStorage) ReleaseExpired(ctx context.Context, timeout time.Duration) (int, repository.DatabaseError) {
+	return f.base.ReleaseExpired(timeout), nil
+}
+
+func (f FlatStorage) Queue(ctx context.Context, req models.ModerationQueueRequest) ([]models.Flat, repository.DatabaseError) {
+	return f.base.Queue(req), nil
+}
@@ -21,15 +21,34 @@ func NewHouseStorage(base *Base) HouseStorage {
 }
 
 func (f HouseStorage) Create(ctx context.Context, req models.HouseCreateRequest) (models.HouseCreateResponse, repository.DatabaseError) {
+	if _, err := f.base.HouseByAddress(req.AddressNormalized); err == nil {
+		return models.HouseCreateResponse{}, NewMockError(false, repository.ErrEntityAlreadyExists)
+	}
 	house := models.House{
 		Address:   req.Address,
 		Year:      *req.Year,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Amenities: req.Amenities,
+
+		AddressNormalized: req.AddressNormalized,
 	}
 	if req.Developer != nil {
 		house.Developer = *req.Developer
 	}
+	house.DeveloperID = req.DeveloperID
+	if req.Floors != nil {
+		house.Floors = *req.Floors
+	}
+	if req.BuildingType != nil {
+		house.BuildingType = *req.BuildingType
+	}
+	if house.Amenities == nil {
+		house.Amenities = []models.Amenity{}
+	}
 
 	id := f.base.AddHouse(house)
 	house.ID = id
@@ -39,6 +58,66 @@ func (f HouseStorage) Create(ctx context.Context, req models.HouseCreateRequest)
 	}, nil
 }
 
+func (f HouseStorage) Get(ctx context.Context, id int) (models.House, repository.DatabaseError) {
+	house, err := f.base.GetHouse(id)
+	if err != nil {
+		return models.House{}, NewMockError(false, err)
+	}
+	return house, nil
+}
+
+func (f HouseStorage) GetByAddress(ctx context.Context, normalized string) (models.House, repository.DatabaseError) {
+	house, err := f.base.HouseByAddress(normalized)
+	if err != nil {
+		return models.House{}, NewMockError(false, err)
+	}
+	return house, nil
+}
+
 func (f HouseStorage) Flats(ctx context.Context, req models.HouseGetFlatsRequest, user models.User) (models.HouseGetFlatsResponse, repository.DatabaseError) {
 	return f.base.Flats(req, user)
 }
+
+func (f HouseStorage) List(ctx context.Context, req models.HouseListRequest, user models.User) ([]models.House, repository.DatabaseError) {
+	return f.base.Houses(req, user), nil
+}
+
+func (f HouseStorage) Update(ctx context.Context, req models.HouseUpdateRequest) (models.HouseUpdateResponse, repository.DatabaseError) {
+	house, err := f.base.UpdateHouse(req)
+	if err != nil {
+		return models.HouseUpdateResponse{}, NewMockError(false, err)
+	}
+
+	return models.HouseUpdateResponse{
+		House: house,
+	}, nil
+}
+
+func (f HouseStorage) Archive(ctx context.Context, id int) (models.House, repository.DatabaseError) {
+	house, err := f.base.ArchiveHouse(id)
+	if err != nil {
+		return models.House{}, NewMockError(false, err)
+	}
+
+	return house, nil
+}
+
+func (f HouseStorage) Nearby(ctx context.Context, req models.HouseNearbyRequest, user models.User) ([]models.HouseLocation, repository.DatabaseError) {
+	return f.base.Nearby(req, user), nil
+}
+
+func (f HouseStorage) Within(ctx context.Context, req models.HouseWithinRequest, user models.User) ([]models.HouseLocation, repository.DatabaseError) {
+	return f.base.Within(req, user), nil
+}
+
+func (f HouseStorage) Stats(ctx context.Context, id int, user models.User) (models.HouseStatsResponse, repository.DatabaseError) {
+	stats, err := f.base.HouseStats(id, user)
+	if err != nil {
+		return models.HouseStatsResponse{}, NewMockError(false, err)
+	}
+	return stats, nil
+}
+
+func (f HouseStorage) Search(ctx context.Context, req models.HouseSearchRequest, user models.User) ([]models.HouseSearchResult, repository.DatabaseError) {
+	return f.base.SearchHouses(req, user), nil
+}
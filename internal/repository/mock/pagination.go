@@ -0,0 +1,55 @@
+package mock
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+)
+
+// compareKeys compares sort keys as integers or timestamps if possible, as strings otherwise
+func compareKeys(a, b string) int {
+	if x, err := strconv.Atoi(a); err == nil {
+		if y, err := strconv.Atoi(b); err == nil {
+			return x - y
+		}
+	}
+	if x, err := time.Parse(time.RFC3339Nano, a); err == nil {
+		if y, err := time.Parse(time.RFC3339Nano, b); err == nil {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func compareCursors(a, b models.Cursor) int {
+	if c := compareKeys(a.Value, b.Value); c != 0 {
+		return c
+	}
+	return a.ID - b.ID
+}
+
+// paginate sorts items by key and returns up to limit+1 items placed after the cursor
+func paginate[T any](items []T, key func(T) models.Cursor, page models.PageRequest) []T {
+	sign := 1
+	if page.Order == models.Desc {
+		sign = -1
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return sign*compareCursors(key(items[i]), key(items[j])) < 0
+	})
+
+	result := make([]T, 0, page.Limit+1)
+	for _, item := range items {
+		if page.After != nil && sign*compareCursors(key(item), *page.After) <= 0 {
+			continue
+		}
+		result = append(result, item)
+		if len(result) == page.Limit+1 {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,157 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
+)
+
+type ReportStorage struct {
+	base *Base
+}
+
+var _ repository.ReportStorage = ReportStorage{}
+
+func NewReportStorage(base *Base) ReportStorage {
+	return ReportStorage{
+		base: base,
+	}
+}
+
+func (r ReportStorage) FlatsPerDay(ctx context.Context, from, to time.Time) ([]models.DailyFlats, repository.DatabaseError) {
+	return r.base.FlatsPerDay(from, to), nil
+}
+
+func (r ReportStorage) ModeratorThroughput(ctx context.Context, from, to time.Time) ([]models.ModeratorThroughput, repository.DatabaseError) {
+	return r.base.ModeratorThroughput(from, to), nil
+}
+
+func (r ReportStorage) DeveloperPrices(ctx context.Context, from, to time.Time) ([]models.DeveloperPrice, repository.DatabaseError) {
+	return r.base.DeveloperPrices(from, to), nil
+}
+
+func inPeriod(t, from, to time.Time) bool {
+	return !t.Before(from) && !t.After(to)
+}
+
+func (b Base) FlatsPerDay(from, to time.Time) []models.DailyFlats {
+	var days []models.DailyFlats
+	index := make(map[string]int)
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(models.ReportDayLayout)
+		index[key] = len(days)
+		days = append(days, models.DailyFlats{Day: key})
+	}
+
+	for _, flat := range b.flats {
+		if inPeriod(flat.CreatedAt, from, to) {
+			days[index[flat.CreatedAt.UTC().Format(models.ReportDayLayout)]].Created++
+		}
+	}
+	for _, events := range b.events {
+		for _, event := range events {
+			if !inPeriod(event.CreatedAt, from, to) {
+				continue
+			}
+			day := &days[index[event.CreatedAt.UTC().Format(models.ReportDayLayout)]]
+			switch event.Status {
+			case models.Approved:
+				day.Approved++
+			case models.Declined:
+				day.Declined++
+			}
+		}
+	}
+	return days
+}
+
+func (b Base) ModeratorThroughput(from, to time.Time) []models.ModeratorThroughput {
+	byModerator := make(map[uuid.UUID]*models.ModeratorThroughput)
+	for _, events := range b.events {
+		for _, event := range events {
+			if !inPeriod(event.CreatedAt, from, to) {
+				continue
+			}
+			moderator, found := byModerator[event.ModeratorID]
+			if !found {
+				moderator = &models.ModeratorThroughput{}
+				if event.ModeratorID != uuid.Nil {
+					id := event.ModeratorID
+					moderator.ModeratorID = &id
+				}
+				byModerator[event.ModeratorID] = moderator
+			}
+			switch event.Status {
+			case models.Approved:
+				moderator.Approved++
+			case models.Declined:
+				moderator.Declined++
+			}
+			moderator.Total++
+		}
+	}
+
+	moderators := make([]models.ModeratorThroughput, 0, len(byModerator))
+	for _, moderator := range byModerator {
+		moderators = append(moderators, *moderator)
+	}
+	sort.Slice(moderators, func(i, j int) bool {
+		if moderators[i].Total != moderators[j].Total {
+			return moderators[i].Total > moderators[j].Total
+		}
+		if moderators[i].ModeratorID == nil || moderators[j].ModeratorID == nil {
+			return moderators[j].ModeratorID == nil
+		}
+		return moderators[i].ModeratorID.String() < moderators[j].ModeratorID.String()
+	})
+	return moderators
+}
+
+func (b Base) DeveloperPrices(from, to time.Time) []models.DeveloperPrice {
+	type group struct {
+		developer string
+		year      int
+	}
+	prices := make(map[group][]int)
+	developers := make(map[group]*int)
+	for _, flat := range b.flats {
+		if flat.Status != models.Approved {
+			continue
+		}
+		var approvedAt time.Time
+		for _, event := range b.events[flat.ID] {
+			if event.Status == models.Approved && event.CreatedAt.After(approvedAt) {
+				approvedAt = event.CreatedAt
+			}
+		}
+		if !inPeriod(approvedAt, from, to) {
+			continue
+		}
+		house := b.houses[flat.HouseID]
+		key := group{developer: house.Developer, year: house.Year}
+		prices[key] = append(prices[key], flat.Price)
+		developers[key] = house.DeveloperID
+	}
+
+	result := make([]models.DeveloperPrice, 0, len(prices))
+	for key, values := range prices {
+		result = append(result, models.DeveloperPrice{
+			DeveloperID: developers[key],
+			Developer:   key.developer,
+			Year:        key.year,
+			Flats:       len(values),
+			MedianPrice: median(values),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Developer != result[j].Developer {
+			return result[i].Developer < result[j].Developer
+		}
+		return result[i].Year < result[j].Year
+	})
+	return result
+}
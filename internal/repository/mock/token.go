@@ -0,0 +1,195 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
+)
+
+type TokenStorage struct {
+	base *Base
+}
+
+var _ repository.TokenStorage = TokenStorage{}
+
+func NewTokenStorage(base *Base) TokenStorage {
+	return TokenStorage{
+		base: base,
+	}
+}
+
+func (t TokenStorage) IsRevoked(ctx context.Context, token models.Token) (bool, repository.DatabaseError) {
+	return t.base.IsRevoked(token), nil
+}
+
+func (t TokenStorage) RevokeToken(ctx context.Context, token models.Token) repository.DatabaseError {
+	t.base.RevokeToken(token.ID, token.ExpiresAt)
+	t.base.RevokeTokenFamily(token.SessionID)
+	return nil
+}
+
+func (t TokenStorage) RevokeUserTokens(ctx context.Context, id uuid.UUID, at time.Time) repository.DatabaseError {
+	t.base.RevokeUserTokens(id, at)
+	return nil
+}
+
+func (t TokenStorage) AddRefreshToken(ctx context.Context, token models.RefreshToken) repository.DatabaseError {
+	t.base.AddRefreshToken(token)
+	return nil
+}
+
+func (t TokenStorage) RefreshToken(ctx context.Context, hash string) (models.RefreshToken, repository.DatabaseError) {
+	token, found := t.base.RefreshToken(hash)
+	if !found {
+		return models.RefreshToken{}, NewMockError(false, repository.ErrEntityNotFound)
+	}
+	return token, nil
+}
+
+func (t TokenStorage) RotateRefreshToken(ctx context.Context, used uuid.UUID, session models.Session, next models.RefreshToken) repository.DatabaseError {
+	token, found := t.base.refreshTokens[used]
+	if !found || token.UsedAt != nil || token.RevokedAt != nil {
+		return NewMockError(false, repository.ErrNoRowsAffected)
+	}
+	if !t.base.TouchSession(session) {
+		return NewMockError(false, repository.ErrEntityNotFound)
+	}
+	t.base.UseRefreshToken(used)
+	t.base.AddRefreshToken(next)
+	return nil
+}
+
+func (t TokenStorage) RevokeTokenFamily(ctx context.Context, family uuid.UUID) repository.DatabaseError {
+	t.base.RevokeTokenFamily(family)
+	return nil
+}
+
+func (t TokenStorage) AddSession(ctx context.Context, session models.Session) repository.DatabaseError {
+	t.base.AddSession(session)
+	return nil
+}
+
+func (t TokenStorage) Sessions(ctx context.Context, id uuid.UUID) ([]models.Session, repository.DatabaseError) {
+	return t.base.Sessions(id), nil
+}
+
+func (t TokenStorage) RevokeSession(ctx context.Context, userID, id uuid.UUID) repository.DatabaseError {
+	session, found := t.base.sessions[id]
+	if !found || session.UserID != userID || session.RevokedAt != nil {
+		return NewMockError(false, repository.ErrEntityNotFound)
+	}
+	t.base.RevokeTokenFamily(id)
+	return nil
+}
+
+func (t TokenStorage) DeleteExpiredRevoked(ctx context.Context, before time.Time) (int, repository.DatabaseError) {
+	return t.base.DeleteExpiredRevoked(before), nil
+}
+
+func (b Base) RevokeToken(jti uuid.UUID, expiresAt time.Time) {
+	b.revoked[jti] = expiresAt
+}
+
+func (b Base) DeleteExpiredRevoked(before time.Time) int {
+	var cnt int
+	for jti, expiresAt := range b.revoked {
+		if expiresAt.Before(before) {
+			delete(b.revoked, jti)
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func (b Base) IsRevoked(token models.Token) bool {
+	if _, found := b.revoked[token.ID]; found {
+		return true
+	}
+	if session, found := b.sessions[token.SessionID]; found && session.RevokedAt != nil {
+		return true
+	}
+	revokedAt, found := b.userRevoked[token.UserID]
+	return found && revokedAt.After(token.IssuedAt)
+}
+
+// RevokeUserTokens revokes all sessions of the user and tokens issued before the time
+func (b Base) RevokeUserTokens(id uuid.UUID, at time.Time) {
+	b.userRevoked[id] = at
+	for key, session := range b.sessions {
+		if session.UserID == id {
+			b.RevokeTokenFamily(key)
+		}
+	}
+}
+
+func (b Base) AddRefreshToken(token models.RefreshToken) {
+	b.refreshTokens[token.ID] = token
+}
+
+func (b Base) RefreshToken(hash string) (models.RefreshToken, bool) {
+	for _, token := range b.refreshTokens {
+		if token.Hash == hash {
+			return token, true
+		}
+	}
+	return models.RefreshToken{}, false
+}
+
+func (b Base) UseRefreshToken(id uuid.UUID) bool {
+	token, found := b.refreshTokens[id]
+	if !found || token.UsedAt != nil || token.RevokedAt != nil {
+		return false
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	b.refreshTokens[id] = token
+	return true
+}
+
+// RevokeTokenFamily revokes the session and all its refresh tokens
+func (b Base) RevokeTokenFamily(family uuid.UUID) {
+	now := time.Now()
+	if session, found := b.sessions[family]; found && session.RevokedAt == nil {
+		session.RevokedAt = &now
+		b.sessions[family] = session
+	}
+	for id, token := range b.refreshTokens {
+		if token.FamilyID == family && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			b.refreshTokens[id] = token
+		}
+	}
+}
+
+func (b Base) AddSession(session models.Session) {
+	b.sessions[session.ID] = session
+}
+
+func (b Base) TouchSession(session models.Session) bool {
+	current, found := b.sessions[session.ID]
+	if !found || current.RevokedAt != nil {
+		return false
+	}
+	current.UserAgent, current.IP = session.UserAgent, session.IP
+	current.LastSeenAt, current.ExpiresAt = session.LastSeenAt, session.ExpiresAt
+	b.sessions[session.ID] = current
+	return true
+}
+
+func (b Base) Sessions(userID uuid.UUID) []models.Session {
+	var sessions []models.Session
+	now := time.Now()
+	for _, session := range b.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions
+}
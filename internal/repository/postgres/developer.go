@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DeveloperStorage struct {
+	conn Connection
+}
+
+var _ repository.DeveloperStorage = DeveloperStorage{}
+
+func NewDeveloperStorage(conn Connection) DeveloperStorage {
+	return DeveloperStorage{
+		conn: conn,
+	}
+}
+
+const developerColumns = `id, name, created_at, updated_at`
+
+func scanDeveloper(row pgx.Row) (models.Developer, error) {
+	var developer models.Developer
+	if err := row.Scan(&developer.ID, &developer.Name, &developer.CreatedAt, &developer.UpdatedAt); err != nil {
+		return models.Developer{}, err
+	}
+	return developer, nil
+}
+
+// developerError recognizes reuse of the developer name and missing developer
+func developerError(s string, err error) repository.DatabaseError {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NewError(s, repository.ErrEntityNotFound)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return NewError(s, repository.ErrEntityAlreadyExists)
+	}
+	return NewError(s, err)
+}
+
+func (d DeveloperStorage) Create(ctx context.Context, req models.DeveloperCreateRequest) (models.Developer, repository.DatabaseError) {
+	query := `INSERT INTO developers (name) VALUES ($1) RETURNING ` + developerColumns
+
+	developer, err := scanDeveloper(d.conn.PC.QueryRow(ctx, query, strings.TrimSpace(req.Name)))
+	if err != nil {
+		return models.Developer{}, developerError(fmt.Sprintf("can't create developer %s", req.Name), err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperStorage) Get(ctx context.Context, id int) (models.Developer, repository.DatabaseError) {
+	query := `SELECT ` + developerColumns + ` FROM developers WHERE id = $1`
+
+	developer, err := scanDeveloper(d.conn.PC.QueryRow(ctx, query, id))
+	if err != nil {
+		return models.Developer{}, developerError(fmt.Sprintf("can't get developer with id %d", id), err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperStorage) GetByName(ctx context.Context, name string) (models.Developer, repository.DatabaseError) {
+	query := `SELECT ` + developerColumns + ` FROM developers WHERE LOWER(name) = LOWER($1)`
+
+	developer, err := scanDeveloper(d.conn.PC.QueryRow(ctx, query, strings.TrimSpace(name)))
+	if err != nil {
+		return models.Developer{}, developerError(fmt.Sprintf("can't get developer with name %s", name), err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperStorage) List(ctx context.Context) ([]models.Developer, repository.DatabaseError) {
+	query := `SELECT ` + developerColumns + ` FROM developers ORDER BY name, id`
+
+	rows, err := d.conn.PC.Query(ctx, query)
+	if err != nil {
+		return nil, NewError("can't get list of developers", err)
+	}
+	defer rows.Close()
+	var developers []models.Developer
+	for rows.Next() {
+		developer, err := scanDeveloper(rows)
+		if err != nil {
+			return nil, NewError("can't scan developer", err)
+		}
+		developers = append(developers, developer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't get list of developers", err)
+	}
+
+	return developers, nil
+}
+
+func (d DeveloperStorage) Update(ctx context.Context, req models.DeveloperUpdateRequest) (models.Developer, repository.DatabaseError) {
+	query := `UPDATE developers SET name = COALESCE($2, name), updated_at = NOW() WHERE id = $1 RETURNING ` + developerColumns
+	housesQuery := `UPDATE houses SET developer = $2, updated_at = NOW() WHERE developer_id = $1 AND developer IS DISTINCT FROM $2`
+	s := fmt.Sprintf("can't update developer %d", req.ID)
+
+	tx, err := d.conn.PC.Begin(ctx)
+	if err != nil {
+		return models.Developer{}, NewError(s, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var name *string
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		name = &trimmed
+	}
+	developer, err := scanDeveloper(tx.QueryRow(ctx, query, req.ID, name))
+	if err != nil {
+		return models.Developer{}, developerError(s, err)
+	}
+	if _, err := tx.Exec(ctx, housesQuery, developer.ID, developer.Name); err != nil {
+		return models.Developer{}, NewError(s, fmt.Errorf("can't rename developer of houses: %w", err))
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Developer{}, NewError(s, err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperStorage) Delete(ctx context.Context, id int) repository.DatabaseError {
+	query := `DELETE FROM developers WHERE id = $1`
+	s := fmt.Sprintf("can't delete developer %d", id)
+
+	tag, err := d.conn.PC.Exec(ctx, query, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return NewError(s, repository.ErrEntityInUse)
+		}
+		return NewError(s, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(s, repository.ErrEntityNotFound)
+	}
+
+	return nil
+}
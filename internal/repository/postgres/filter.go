@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+)
+
+// filter collects conditions of WHERE clause and its positional arguments
+type filter struct {
+	conds []string
+	args  []any
+}
+
+// arg adds new argument and returns its placeholder
+func (f *filter) arg(v any) string {
+	f.args = append(f.args, v)
+	return "$" + strconv.Itoa(len(f.args))
+}
+
+func (f *filter) add(cond string) {
+	f.conds = append(f.conds, cond)
+}
+
+// keyset adds condition for the page after cursor, sorted by column and id column
+func (f *filter) keyset(column, idColumn, cast string, order models.SortOrder, after *models.Cursor) {
+	if after == nil {
+		return
+	}
+	op := ">"
+	if order == models.Desc {
+		op = "<"
+	}
+	f.add(fmt.Sprintf("(%s, %s) %s (%s::%s, %s)", column, idColumn, op, f.arg(after.Value), cast, f.arg(after.ID)))
+}
+
+func (f *filter) where() string {
+	if len(f.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conds, " AND ")
+}
+
+func orderBy(column, idColumn string, order models.SortOrder) string {
+	dir := "ASC"
+	if order == models.Desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", column, dir, idColumn, dir)
+}
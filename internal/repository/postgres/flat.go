@@ -2,15 +2,19 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/domain/moderation"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type FlatStorage struct {
@@ -26,33 +30,163 @@ func NewFlatStorage(conn Connection) FlatStorage {
 }
 
 func (f FlatStorage) Create(ctx context.Context, req models.FlatCreateRequest) (models.FlatCreateResponse, repository.DatabaseError) {
-	query := `INSERT INTO flats (house_id, price, rooms, status) VALUES ($1, $2, $3, $4) RETURNING id`
-	var id int
-	if err := f.conn.PC.QueryRow(ctx, query, req.HouseID, *(req.Price), req.Room, models.Created).Scan(&id); err != nil {
+	query := `INSERT INTO flats (house_id, number, floor, total_area, living_area, price, rooms, status, created_by)
+	SELECT $1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9
+	WHERE NOT EXISTS (SELECT 1 FROM houses WHERE id = $1 AND archived_at IS NOT NULL) RETURNING id, created_at`
+	var (
+		id        int
+		createdAt time.Time
+	)
+	if err := f.conn.PC.QueryRow(ctx, query, req.HouseID, req.Number, req.Floor, req.TotalArea, req.LivingArea,
+		*(req.Price), req.Room, models.Created, nullUUID(req.CreatedBy)).Scan(&id, &createdAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.FlatCreateResponse{}, NewError(fmt.Sprintf("can't create new flat for house %d", req.HouseID), repository.ErrEntityArchived)
+		}
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
 			return models.FlatCreateResponse{}, NewError("can't create new flat", fmt.Errorf("house %d is not exist", req.HouseID))
 		}
-		return models.FlatCreateResponse{}, NewError(fmt.Sprintf("can't create new flat for house %d", req.HouseID), err)
+		return models.FlatCreateResponse{}, flatQueryError(fmt.Sprintf("can't create new flat for house %d", req.HouseID), err)
 	}
 
 	return models.FlatCreateResponse{
-		Flat: models.Flat{
-			ID:      id,
-			HouseID: req.HouseID,
-			Price:   *req.Price,
-			Room:    req.Room,
-			Status:  models.Created,
-		},
+		Flat: newFlat(req, id, createdAt),
 	}, nil
 }
 
-func (f FlatStorage) Get(ctx context.Context, req models.Flat) (models.Flat, repository.DatabaseError) {
-	query := `SELECT id, house_id, price, rooms, status FROM flats WHERE id = $1`
+// newFlat builds just created flat from the request
+func newFlat(req models.FlatCreateRequest, id int, createdAt time.Time) models.Flat {
+	flat := models.Flat{
+		ID:      id,
+		HouseID: req.HouseID,
+		Number:  req.Number,
+		Price:   *req.Price,
+		Room:    req.Room,
+		Status:  models.Created,
+
+		CreatedAt: createdAt,
+		CreatedBy: req.CreatedBy,
+	}
+	if req.Floor != nil {
+		floor := *req.Floor
+		flat.Floor = &floor
+	}
+	if req.TotalArea != nil {
+		flat.TotalArea = *req.TotalArea
+	}
+	if req.LivingArea != nil {
+		flat.LivingArea = *req.LivingArea
+	}
+	return flat
+}
+
+func (f FlatStorage) CreateBatch(ctx context.Context, houseID int, reqs []models.FlatCreateRequest) ([]repository.FlatBatchResult, repository.DatabaseError) {
+	houseQuery := `SELECT archived_at IS NOT NULL FROM houses WHERE id = $1 FOR SHARE`
+	query := `INSERT INTO flats (house_id, number, floor, total_area, living_area, price, rooms, status, created_by)
+	VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`
+	s := fmt.Sprintf("can't create flats for house %d", houseID)
+
+	tx, err := f.conn.PC.Begin(ctx)
+	if err != nil {
+		return nil, NewError(s, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var archived bool
+	if err := tx.QueryRow(ctx, houseQuery, houseID).Scan(&archived); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, NewError(s, repository.ErrEntityNotFound)
+		}
+		return nil, NewError(s, err)
+	}
+	if archived {
+		return nil, NewError(s, repository.ErrEntityArchived)
+	}
+
+	results := make([]repository.FlatBatchResult, len(reqs))
+	for i, req := range reqs {
+		req.HouseID = houseID
+		sp, err := tx.Begin(ctx) // savepoint, so failure of one flat doesn't abort the others
+		if err != nil {
+			return nil, NewError(s, err)
+		}
+		var (
+			id        int
+			createdAt time.Time
+		)
+		err = sp.QueryRow(ctx, query, houseID, req.Number, req.Floor, req.TotalArea, req.LivingArea,
+			*req.Price, req.Room, models.Created, nullUUID(req.CreatedBy)).Scan(&id, &createdAt)
+		if err != nil {
+			if rbErr := sp.Rollback(ctx); rbErr != nil {
+				return nil, NewError(s, rbErr)
+			}
+			results[i].Err = flatQueryError(s, err).Cause()
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, NewError(s, err)
+		}
+		results[i].Flat = newFlat(req, id, createdAt)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, NewError(s, err)
+	}
+
+	return results, nil
+}
+
+// flatQueryError recognizes reuse of the flat number in the house
+func flatQueryError(s string, err error) repository.DatabaseError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return NewError(s, repository.ErrEntityAlreadyExists)
+	}
+	return NewError(s, err)
+}
+
+const flatColumns = `id, house_id, number, floor, total_area, living_area, price, rooms, status, created_at,
+	created_by, moderator_id, moderation_started_at`
+
+func scanFlat(row pgx.Row, extra ...any) (models.Flat, error) {
+	var (
+		flat                   models.Flat
+		number, floor          pgtype.Int4
+		totalArea, livingArea  pgtype.Float8
+		createdBy, moderatorID pgtype.UUID
+	)
+	dest := []any{&flat.ID, &flat.HouseID, &number, &floor, &totalArea, &livingArea, &flat.Price, &flat.Room,
+		&flat.Status, &flat.CreatedAt, &createdBy, &moderatorID, &flat.ModerationStartedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return models.Flat{}, err
+	}
+	flat.Number = int(number.Int32)
+	if floor.Valid {
+		value := int(floor.Int32)
+		flat.Floor = &value
+	}
+	flat.TotalArea, flat.LivingArea = totalArea.Float64, livingArea.Float64
+	if createdBy.Valid {
+		flat.CreatedBy = createdBy.Bytes
+	}
+	if moderatorID.Valid {
+		flat.ModeratorID = moderatorID.Bytes
+	}
+	return flat, nil
+}
 
-	var result models.Flat
+// nullUUID converts empty uuid to NULL value
+func nullUUID(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
 
-	if err := f.conn.PC.QueryRow(ctx, query, req.ID).Scan(&result.ID, &result.HouseID, &result.Price, &result.Room, &result.Status); err != nil {
+func (f FlatStorage) Get(ctx context.Context, req models.Flat) (models.Flat, repository.DatabaseError) {
+	query := `SELECT ` + flatColumns + ` FROM flats WHERE id = $1`
+
+	result, err := scanFlat(f.conn.PC.QueryRow(ctx, query, req.ID))
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Flat{}, NewError(fmt.Sprintf("can't get flat with id %d", req.ID), repository.ErrEntityNotFound)
 		}
@@ -62,36 +196,381 @@ func (f FlatStorage) Get(ctx context.Context, req models.Flat) (models.Flat, rep
 	return result, nil
 }
 
-func (f FlatStorage) Update(ctx context.Context, req models.FlatUpdateRequest) (models.FlatUpdateResponse, repository.DatabaseError) {
-	pattern := `UPDATE flats SET %s WHERE id = $1`
+const priceHistoryInsert = `INSERT INTO flat_price_history (flat_id, old_price, new_price, changed_by) VALUES ($1, $2, $3, $4)`
 
-	var sets []string
-	if req.Price != nil {
-		sets = append(sets, fmt.Sprintf("price = %d", *req.Price))
+// updateFlat sets columns of the flat with id passed as $1 if it satisfies condition, change of the price is saved to history
+func updateFlat(ctx context.Context, tx pgx.Tx, set, cond string, args []any, changedBy uuid.UUID) (models.Flat, error) {
+	if cond != "" {
+		cond = " AND " + cond
 	}
-	if req.Room > 0 {
-		sets = append(sets, fmt.Sprintf("rooms = %d", req.Room))
+	query := `UPDATE flats SET ` + set + ` FROM (SELECT id AS old_id, price AS old_price FROM flats WHERE id = $1 FOR UPDATE) old
+	WHERE id = old_id` + cond + ` RETURNING ` + flatColumns + `, old_price`
+
+	var oldPrice int
+	flat, err := scanFlat(tx.QueryRow(ctx, query, args...), &oldPrice)
+	if err != nil {
+		return models.Flat{}, err
+	}
+	if oldPrice != flat.Price {
+		if _, err := tx.Exec(ctx, priceHistoryInsert, flat.ID, oldPrice, flat.Price, nullUUID(changedBy)); err != nil {
+			return models.Flat{}, fmt.Errorf("can't save price change: %w", err)
+		}
+	}
+	return flat, nil
+}
+
+// transitionFailure explains why status of the flat has not been changed by conditional update
+func (f FlatStorage) transitionFailure(ctx context.Context, id int, to models.FlatStatus) repository.DatabaseError {
+	s := fmt.Sprintf("can't change status of flat %d", id)
+	flat, dbErr := f.Get(ctx, models.Flat{ID: id})
+	if dbErr != nil {
+		return dbErr
+	}
+	if err := moderation.Transit(flat.Status, to); err != nil {
+		return NewError(s, err)
+	}
+	return NewError(s, repository.ErrNoRowsAffected)
+}
+
+var flatSortColumns = map[string]struct{ column, cast string }{
+	models.FlatSortID:        {"id", "int"},
+	models.FlatSortPrice:     {"price", "int"},
+	models.FlatSortRoom:      {"rooms", "int"},
+	models.FlatSortCreatedAt: {"created_at", "timestamptz"},
+}
+
+func (f FlatStorage) List(ctx context.Context, req models.FlatListRequest, user models.User) ([]models.Flat, repository.DatabaseError) {
+	var flt filter
+	if req.HouseID != nil {
+		flt.add("house_id = " + flt.arg(*req.HouseID))
+	}
+	if req.PriceFrom != nil {
+		flt.add("price >= " + flt.arg(*req.PriceFrom))
+	}
+	if req.PriceTo != nil {
+		flt.add("price <= " + flt.arg(*req.PriceTo))
+	}
+	if req.Room != nil {
+		flt.add("rooms = " + flt.arg(*req.Room))
+	}
+	if req.CreatedBy != nil {
+		flt.add("created_by = " + flt.arg(*req.CreatedBy))
+	}
+	if user.UserType == models.Client && req.CreatedBy == nil {
+		flt.add("status = " + flt.arg(models.Approved))
+		flt.add("house_id IN (SELECT id FROM houses WHERE archived_at IS NULL)")
+	} else if req.Status != nil {
+		flt.add("status = " + flt.arg(*req.Status))
+	}
+
+	flats, err := f.page(ctx, flt, req.PageRequest)
+	if err != nil {
+		return nil, NewError(fmt.Sprintf("can't get list of flats for user type %s", user.UserType), err)
+	}
+
+	return flats, nil
+}
+
+// page selects flats matching the filter, sorted and limited according to the page
+func (f FlatStorage) page(ctx context.Context, flt filter, page models.PageRequest) ([]models.Flat, error) {
+	sort, found := flatSortColumns[page.SortBy]
+	if !found {
+		sort = flatSortColumns[models.FlatSortID]
+	}
+	flt.keyset(sort.column, "id", sort.cast, page.Order, page.After)
+
+	query := `SELECT ` + flatColumns + ` FROM flats` + flt.where() + orderBy(sort.column, "id", page.Order) +
+		` LIMIT ` + flt.arg(page.Limit+1)
+
+	rows, err := f.conn.PC.Query(ctx, query, flt.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var flats []models.Flat
+	for rows.Next() {
+		flat, err := scanFlat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan flat: %w", err)
+		}
+		flats = append(flats, flat)
+	}
+
+	return flats, rows.Err()
+}
+
+func (f FlatStorage) Queue(ctx context.Context, req models.ModerationQueueRequest) ([]models.Flat, repository.DatabaseError) {
+	var flt filter
+	flt.add("status = " + flt.arg(models.Created))
+	houses := "SELECT id FROM houses WHERE archived_at IS NULL"
+	if req.Developer != nil {
+		houses += " AND LOWER(developer) = LOWER(" + flt.arg(*req.Developer) + ")"
+	}
+	flt.add("house_id IN (" + houses + ")")
+	if req.HouseID != nil {
+		flt.add("house_id = " + flt.arg(*req.HouseID))
+	}
+
+	flats, err := f.page(ctx, flt, req.PageRequest)
+	if err != nil {
+		return nil, NewError("can't get moderation queue", err)
+	}
+
+	return flats, nil
+}
+
+func (f FlatStorage) Withdraw(ctx context.Context, id int) (models.Flat, repository.DatabaseError) {
+	query := `UPDATE flats SET status = $2 WHERE id = $1 AND status = ANY($3) RETURNING ` + flatColumns
+
+	flat, err := scanFlat(f.conn.PC.QueryRow(ctx, query, id, models.Withdrawn, moderation.Sources(models.Withdrawn)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Flat{}, f.transitionFailure(ctx, id, models.Withdrawn)
+		}
+		return models.Flat{}, NewError(fmt.Sprintf("can't withdraw flat %d", id), err)
 	}
-	if req.Status != nil {
-		sets = append(sets, fmt.Sprintf(`status = '%s'`, *req.Status))
+
+	return flat, nil
+}
+
+// flatAttributesSet keeps attributes of the flat which are not passed in the request, values are taken from flatAttributes starting from $4
+const flatAttributesSet = `number = COALESCE($4, number), floor = COALESCE($5, floor), total_area = COALESCE($6, total_area),
+	living_area = COALESCE($7, living_area), price = COALESCE($8, price), rooms = COALESCE($9, rooms)`
+
+func flatAttributes(req models.FlatUpdateRequest) []any {
+	var room *int
+	if req.Room > 0 {
+		room = &req.Room
 	}
-	query := fmt.Sprintf(pattern, strings.Join(sets, ","))
+	return []any{req.Number, req.Floor, req.TotalArea, req.LivingArea, req.Price, room}
+}
+
+func (f FlatStorage) Resubmit(ctx context.Context, req models.FlatUpdateRequest) (models.Flat, repository.DatabaseError) {
+	set := `status = $2, ` + flatAttributesSet + `, moderator_id = NULL, moderation_started_at = NULL`
+	s := fmt.Sprintf("can't resubmit flat %d", req.ID)
 
-	tag, err := f.conn.PC.Exec(ctx, query, req.ID)
+	tx, err := f.conn.PC.Begin(ctx)
 	if err != nil {
-		return models.FlatUpdateResponse{}, NewError("can't update flat", err)
+		return models.Flat{}, NewError(s, err)
 	}
+	defer tx.Rollback(ctx)
 
-	if tag.RowsAffected() == 0 {
-		return models.FlatUpdateResponse{}, NewError("can't update flat", repository.ErrNoRowsAffected)
+	args := append([]any{req.ID, models.Created, models.Declined}, flatAttributes(req)...)
+	flat, err := updateFlat(ctx, tx, set, "status = $3", args, req.UpdatedBy)
+	if err == nil {
+		if err := tx.Commit(ctx); err != nil {
+			return models.Flat{}, NewError(s, err)
+		}
+		return flat, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.Flat{}, flatQueryError(s, err)
 	}
 
 	flat, dbErr := f.Get(ctx, models.Flat{ID: req.ID})
 	if dbErr != nil {
-		return models.FlatUpdateResponse{}, dbErr
+		return models.Flat{}, dbErr
 	}
+	if err := moderation.CheckResubmit(flat); err != nil {
+		return models.Flat{}, NewError(s, err)
+	}
+	return models.Flat{}, NewError(s, repository.ErrNoRowsAffected)
+}
 
-	return models.FlatUpdateResponse{
-		Flat: flat,
-	}, nil
+// claimQuery takes the flat on moderation, status before moderation is kept to restore it if the claim expires
+const claimQuery = `UPDATE flats SET status = $2, moderator_id = $3, moderation_started_at = NOW(),
+	previous_status = CASE WHEN status = $2 THEN previous_status ELSE status END
+	WHERE id = $1 AND (status = ANY($4) OR (status = $2 AND (moderator_id IS NOT DISTINCT FROM $3
+		OR moderation_started_at IS NULL OR moderation_started_at < NOW() - make_interval(secs => $5))))
+	RETURNING ` + flatColumns
+
+func claimArgs(id int, moderator uuid.UUID, timeout time.Duration) []any {
+	return []any{id, models.OnModeration, nullUUID(moderator), moderation.Sources(models.OnModeration), timeout.Seconds()}
+}
+
+func (f FlatStorage) StartModeration(ctx context.Context, id int, moderator uuid.UUID, timeout time.Duration) (models.Flat, repository.DatabaseError) {
+	flat, err := scanFlat(f.conn.PC.QueryRow(ctx, claimQuery, claimArgs(id, moderator, timeout)...))
+	if err != nil {
+		return models.Flat{}, f.startFailure(ctx, id, moderator, timeout, err)
+	}
+
+	return flat, nil
+}
+
+// startFailure explains why the flat is not claimed
+func (f FlatStorage) startFailure(ctx context.Context, id int, moderator uuid.UUID, timeout time.Duration, err error) repository.DatabaseError {
+	s := fmt.Sprintf("can't start moderation of flat %d", id)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return NewError(s, err)
+	}
+
+	flat, dbErr := f.Get(ctx, models.Flat{ID: id})
+	if dbErr != nil {
+		return dbErr
+	}
+	if err := moderation.CheckStart(flat, moderator, time.Now(), timeout); err != nil {
+		return NewError(s, err)
+	}
+	return NewError(s, repository.ErrNoRowsAffected)
+}
+
+func (f FlatStorage) FinishModeration(ctx context.Context, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.FlatUpdateResponse, repository.DatabaseError) {
+	s := fmt.Sprintf("can't finish moderation of flat %d", req.ID)
+
+	tx, err := f.conn.PC.Begin(ctx)
+	if err != nil {
+		return models.FlatUpdateResponse{}, NewError(s, err)
+	}
+	defer tx.Rollback(ctx)
+
+	flat, err := finishModeration(ctx, tx, req, moderator, timeout)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.FlatUpdateResponse{}, flatQueryError(s, err)
+		}
+		flat, dbErr := f.Get(ctx, models.Flat{ID: req.ID})
+		if dbErr != nil {
+			return models.FlatUpdateResponse{}, dbErr
+		}
+		if err := moderation.CheckFinish(flat, moderator, *req.Status, time.Now(), timeout); err != nil {
+			return models.FlatUpdateResponse{}, NewError(s, err)
+		}
+		return models.FlatUpdateResponse{}, NewError(s, repository.ErrNoRowsAffected)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.FlatUpdateResponse{}, NewError(s, err)
+	}
+
+	return models.FlatUpdateResponse{Flat: flat}, nil
+}
+
+// Moderate claims the flat and sets its final status in a single transaction, so the flat is never left claimed
+func (f FlatStorage) Moderate(ctx context.Context, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.FlatUpdateResponse, repository.DatabaseError) {
+	s := fmt.Sprintf("can't moderate flat %d", req.ID)
+
+	tx, err := f.conn.PC.Begin(ctx)
+	if err != nil {
+		return models.FlatUpdateResponse{}, NewError(s, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := scanFlat(tx.QueryRow(ctx, claimQuery, claimArgs(req.ID, moderator, timeout)...)); err != nil {
+		return models.FlatUpdateResponse{}, f.startFailure(ctx, req.ID, moderator, timeout, err)
+	}
+	flat, err := finishModeration(ctx, tx, req, moderator, timeout)
+	if err != nil {
+		return models.FlatUpdateResponse{}, flatQueryError(s, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.FlatUpdateResponse{}, NewError(s, err)
+	}
+
+	return models.FlatUpdateResponse{Flat: flat}, nil
+}
+
+// finishModeration sets the final status of the flat claimed by the moderator and saves the moderation event
+func finishModeration(ctx context.Context, tx pgx.Tx, req models.FlatUpdateRequest, moderator uuid.UUID, timeout time.Duration) (models.Flat, error) {
+	set := `status = $2, ` + flatAttributesSet + `, previous_status = NULL`
+	cond := `status = $3 AND moderator_id IS NOT DISTINCT FROM $10 AND moderation_started_at >= NOW() - make_interval(secs => $11)`
+	eventQuery := `INSERT INTO flat_moderation_events (flat_id, moderator_id, status, decline_reason, comment)
+	VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+
+	args := append([]any{req.ID, *req.Status, models.OnModeration}, flatAttributes(req)...)
+	flat, err := updateFlat(ctx, tx, set, cond, append(args, nullUUID(moderator), timeout.Seconds()), moderator)
+	if err != nil {
+		return models.Flat{}, err
+	}
+
+	event := models.ModerationEvent{
+		FlatID:      flat.ID,
+		ModeratorID: moderator,
+		Status:      flat.Status,
+	}
+	if req.DeclineReason != nil {
+		event.DeclineReason = *req.DeclineReason
+	}
+	if req.Comment != nil {
+		event.Comment = *req.Comment
+	}
+	if err := tx.QueryRow(ctx, eventQuery, flat.ID, nullUUID(moderator), flat.Status, req.DeclineReason, req.Comment).Scan(&event.CreatedAt); err != nil {
+		return models.Flat{}, fmt.Errorf("can't save moderation event: %w", err)
+	}
+	flat.Moderation = &event
+
+	return flat, nil
+}
+
+func (f FlatStorage) LastModerationEvents(ctx context.Context, ids []int) (map[int]models.ModerationEvent, repository.DatabaseError) {
+	query := `SELECT DISTINCT ON (flat_id) flat_id, moderator_id, status, decline_reason, comment, created_at
+	FROM flat_moderation_events WHERE flat_id = ANY($1) ORDER BY flat_id, created_at DESC, id DESC`
+
+	rows, err := f.conn.PC.Query(ctx, query, ids)
+	if err != nil {
+		return nil, NewError("can't get moderation events", err)
+	}
+	defer rows.Close()
+	events := make(map[int]models.ModerationEvent, len(ids))
+	for rows.Next() {
+		var (
+			event           models.ModerationEvent
+			moderatorID     pgtype.UUID
+			reason, comment sql.NullString
+		)
+		if err := rows.Scan(&event.FlatID, &moderatorID, &event.Status, &reason, &comment, &event.CreatedAt); err != nil {
+			return nil, NewError("can't scan moderation event", err)
+		}
+		if moderatorID.Valid {
+			event.ModeratorID = moderatorID.Bytes
+		}
+		event.DeclineReason, event.Comment = reason.String, comment.String
+		events[event.FlatID] = event
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't get moderation events", err)
+	}
+
+	return events, nil
+}
+
+func (f FlatStorage) PriceHistory(ctx context.Context, id int) ([]models.PriceChange, repository.DatabaseError) {
+	query := `SELECT flat_id, old_price, new_price, changed_by, changed_at FROM flat_price_history WHERE flat_id = $1 ORDER BY changed_at, id`
+
+	rows, err := f.conn.PC.Query(ctx, query, id)
+	if err != nil {
+		return nil, NewError(fmt.Sprintf("can't get price history of flat %d", id), err)
+	}
+	defer rows.Close()
+	var history []models.PriceChange
+	for rows.Next() {
+		var (
+			change    models.PriceChange
+			changedBy pgtype.UUID
+		)
+		if err := rows.Scan(&change.FlatID, &change.OldPrice, &change.NewPrice, &changedBy, &change.ChangedAt); err != nil {
+			return nil, NewError("can't scan price change", err)
+		}
+		if changedBy.Valid {
+			by := uuid.UUID(changedBy.Bytes)
+			change.ChangedBy = &by
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError(fmt.Sprintf("can't get price history of flat %d", id), err)
+	}
+
+	return history, nil
+}
+
+// ReleaseExpired returns flats with expired moderation to the status they had before the claim
+func (f FlatStorage) ReleaseExpired(ctx context.Context, timeout time.Duration) (int, repository.DatabaseError) {
+	query := `UPDATE flats SET status = COALESCE(previous_status, $1), previous_status = NULL, moderator_id = NULL, moderation_started_at = NULL
+	WHERE status = $2 AND (moderation_started_at IS NULL OR moderation_started_at < NOW() - make_interval(secs => $3))`
+
+	tag, err := f.conn.PC.Exec(ctx, query, models.Created, models.OnModeration, timeout.Seconds())
+	if err != nil {
+		return 0, NewError("can't release flats with expired moderation", err)
+	}
+
+	return int(tag.RowsAffected()), nil
 }
@@ -5,13 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
+	"github.com/antsrp/house_service/internal/domain/address"
+	"github.com/antsrp/house_service/internal/domain/geo"
 	"github.com/antsrp/house_service/internal/domain/models"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type HouseStorage struct {
@@ -27,66 +29,94 @@ func NewHouseStorage(conn Connection) HouseStorage {
 }
 
 func (f HouseStorage) Create(ctx context.Context, req models.HouseCreateRequest) (models.HouseCreateResponse, repository.DatabaseError) {
-	pattern := `INSERT INTO houses (%s) VALUES (%s) RETURNING id, created_at, updated_at`
-	var (
-		id                   int
-		createdAt, updatedAt time.Time
-	)
-	fields := []string{
-		"address",
-		"year",
+	query := `INSERT INTO houses (address, year, developer, floors, building_type, latitude, longitude, amenities, developer_id, address_normalized)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::text[], '{}'), $9, $10) RETURNING ` + houseColumns
+
+	house, err := scanHouse(f.conn.PC.QueryRow(ctx, query, req.Address, req.Year, req.Developer, req.Floors,
+		req.BuildingType, req.Latitude, req.Longitude, req.Amenities, req.DeveloperID, req.AddressNormalized))
+	if err != nil {
+		return models.HouseCreateResponse{}, houseQueryError("can't create new house", err)
 	}
-	values := []string{
-		fmt.Sprintf(`'%s'`, req.Address),
-		strconv.Itoa(*req.Year),
+
+	return models.HouseCreateResponse{
+		House: house,
+	}, nil
+}
+
+// houseQueryError recognizes reuse of the normalized address by another not archived house
+func houseQueryError(s string, err error) repository.DatabaseError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return NewError(s, repository.ErrEntityAlreadyExists)
 	}
-	if req.Developer != nil {
-		fields = append(fields, "developer")
-		values = append(values, fmt.Sprintf(`'%s'`, *req.Developer))
+	return NewError(s, err)
+}
+
+const houseColumns = `id, address, year, developer, created_at, updated_at, archived_at,
+	floors, building_type, latitude, longitude, amenities, developer_id, address_normalized`
+
+// scanHouse reads columns of house and extra columns after them
+func scanHouse(row pgx.Row, extra ...any) (models.House, error) {
+	var (
+		house                                      models.House
+		developer, buildingType, addressNormalized sql.NullString
+		floors                                     sql.NullInt32
+	)
+	dest := []any{&house.ID, &house.Address, &house.Year, &developer, &house.CreatedAt, &house.UpdatedAt, &house.ArchivedAt,
+		&floors, &buildingType, &house.Latitude, &house.Longitude, &house.Amenities, &house.DeveloperID, &addressNormalized}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return models.House{}, err
 	}
-	query := fmt.Sprintf(pattern, strings.Join(fields, ","), strings.Join(values, ","))
-	if err := f.conn.PC.QueryRow(ctx, query).Scan(&id, &createdAt, &updatedAt); err != nil {
-		return models.HouseCreateResponse{}, NewError("can't create new house", err)
+	if developer.Valid {
+		house.Developer = developer.String
 	}
+	house.Floors, house.BuildingType, house.AddressNormalized = int(floors.Int32), buildingType.String, addressNormalized.String
+	return house, nil
+}
 
-	house := models.House{
-		ID:        id,
-		Address:   req.Address,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-	}
+func (f HouseStorage) Get(ctx context.Context, id int) (models.House, repository.DatabaseError) {
+	query := `SELECT ` + houseColumns + ` FROM houses WHERE id = $1`
 
-	if req.Year != nil {
-		house.Year = *req.Year
+	house, err := scanHouse(f.conn.PC.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.House{}, NewError(fmt.Sprintf("can't get house with id %d", id), repository.ErrEntityNotFound)
+		}
+		return models.House{}, NewError(fmt.Sprintf("can't get house by id %d", id), err)
 	}
-	if req.Developer != nil {
-		house.Developer = *req.Developer
+
+	return house, nil
+}
+
+func (f HouseStorage) GetByAddress(ctx context.Context, normalized string) (models.House, repository.DatabaseError) {
+	query := `SELECT ` + houseColumns + ` FROM houses WHERE address_normalized = $1 AND archived_at IS NULL ORDER BY id LIMIT 1`
+
+	house, err := scanHouse(f.conn.PC.QueryRow(ctx, query, normalized))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.House{}, NewError(fmt.Sprintf("can't get house with address %s", normalized), repository.ErrEntityNotFound)
+		}
+		return models.House{}, NewError(fmt.Sprintf("can't get house by address %s", normalized), err)
 	}
 
-	return models.HouseCreateResponse{
-		House: house,
-	}, nil
+	return house, nil
 }
 
 func (f HouseStorage) Flats(ctx context.Context, req models.HouseGetFlatsRequest, user models.User) (models.HouseGetFlatsResponse, repository.DatabaseError) {
-	houseQuery := `SELECT id, address, year, developer, created_at, updated_at FROM houses WHERE id = $1`
-	var (
-		house     models.House
-		developer sql.NullString
-	)
-	if err := f.conn.PC.QueryRow(ctx, houseQuery, req.ID).
-		Scan(&house.ID, &house.Address, &house.Year, &developer, &house.CreatedAt, &house.UpdatedAt); err != nil {
+	houseQuery := `SELECT ` + houseColumns + ` FROM houses WHERE id = $1`
+	if user.UserType == models.Client {
+		houseQuery += ` AND archived_at IS NULL`
+	}
+	house, err := scanHouse(f.conn.PC.QueryRow(ctx, houseQuery, req.ID))
+	if err != nil {
 		s := fmt.Sprintf("can't get house %d", req.ID)
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.HouseGetFlatsResponse{}, NewError(s, repository.ErrEntityNotFound)
 		}
 		return models.HouseGetFlatsResponse{}, NewError(s, err)
 	}
-	if developer.Valid {
-		house.Developer = developer.String
-	}
 
-	query := `SELECT id, house_id, price, rooms, status FROM flats WHERE house_id = $1`
+	query := `SELECT ` + flatColumns + ` FROM flats WHERE house_id = $1`
 
 	if user.UserType == models.Client {
 		query = fmt.Sprintf("%s AND status = '%s'", query, models.Approved)
@@ -99,8 +129,8 @@ func (f HouseStorage) Flats(ctx context.Context, req models.HouseGetFlatsRequest
 	defer rows.Close()
 	var flats []models.Flat
 	for rows.Next() {
-		var flat models.Flat
-		if err := rows.Scan(&flat.ID, &flat.HouseID, &flat.Price, &flat.Room, &flat.Status); err != nil {
+		flat, err := scanFlat(rows)
+		if err != nil {
 			return models.HouseGetFlatsResponse{}, NewError("can't scan flat", err)
 		}
 		flats = append(flats, flat)
@@ -111,3 +141,289 @@ func (f HouseStorage) Flats(ctx context.Context, req models.HouseGetFlatsRequest
 		Flats: flats,
 	}, nil
 }
+
+var houseSortColumns = map[string]struct{ column, cast string }{
+	models.HouseSortID:        {"id", "int"},
+	models.HouseSortYear:      {"COALESCE(year, 0)", "int"},
+	models.HouseSortCreatedAt: {"created_at", "timestamptz"},
+	models.HouseSortUpdatedAt: {"updated_at", "timestamptz"},
+}
+
+func (f HouseStorage) Stats(ctx context.Context, id int, user models.User) (models.HouseStatsResponse, repository.DatabaseError) {
+	houseQuery := `SELECT updated_at, EXTRACT(EPOCH FROM NOW() - updated_at)::bigint FROM houses WHERE id = $1`
+	var flt filter
+	flt.add("house_id = " + flt.arg(id))
+	if user.UserType == models.Client {
+		houseQuery += ` AND archived_at IS NULL`
+		flt.add("status = " + flt.arg(models.Approved))
+	}
+	statusQuery := `SELECT status, COUNT(*) FROM flats` + flt.where() + ` GROUP BY status`
+	priceQuery := `SELECT rooms, COUNT(*), MIN(price), PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY price), MAX(price), AVG(price)::float8
+	FROM flats` + flt.where() + ` GROUP BY rooms ORDER BY rooms`
+	s := fmt.Sprintf("can't get statistics of house %d", id)
+
+	stats := models.HouseStatsResponse{HouseID: id, Statuses: make(map[models.FlatStatus]int), Prices: []models.RoomPriceStats{}}
+	if err := f.conn.PC.QueryRow(ctx, houseQuery, id).Scan(&stats.UpdatedAt, &stats.SinceUpdate); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.HouseStatsResponse{}, NewError(s, repository.ErrEntityNotFound)
+		}
+		return models.HouseStatsResponse{}, NewError(s, err)
+	}
+
+	rows, err := f.conn.PC.Query(ctx, statusQuery, flt.args...)
+	if err != nil {
+		return models.HouseStatsResponse{}, NewError(s, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			status models.FlatStatus
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return models.HouseStatsResponse{}, NewError(s, err)
+		}
+		stats.Statuses[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return models.HouseStatsResponse{}, NewError(s, err)
+	}
+
+	rows, err = f.conn.PC.Query(ctx, priceQuery, flt.args...)
+	if err != nil {
+		return models.HouseStatsResponse{}, NewError(s, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var price models.RoomPriceStats
+		if err := rows.Scan(&price.Rooms, &price.Count, &price.Min, &price.Median, &price.Max, &price.Average); err != nil {
+			return models.HouseStatsResponse{}, NewError(s, err)
+		}
+		stats.Prices = append(stats.Prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return models.HouseStatsResponse{}, NewError(s, err)
+	}
+
+	return stats, nil
+}
+
+func (f HouseStorage) List(ctx context.Context, req models.HouseListRequest, user models.User) ([]models.House, repository.DatabaseError) {
+	var flt filter
+	if user.UserType == models.Client {
+		flt.add("archived_at IS NULL")
+	}
+	if req.Address != nil {
+		flt.add(fmt.Sprintf("address ILIKE '%%' || %s || '%%'", flt.arg(*req.Address)))
+	}
+	if req.Developer != nil {
+		flt.add(fmt.Sprintf("LOWER(developer) = LOWER(%s)", flt.arg(*req.Developer)))
+	}
+	if req.DeveloperID != nil {
+		flt.add("developer_id = " + flt.arg(*req.DeveloperID))
+	}
+	if req.YearFrom != nil {
+		flt.add("year >= " + flt.arg(*req.YearFrom))
+	}
+	if req.YearTo != nil {
+		flt.add("year <= " + flt.arg(*req.YearTo))
+	}
+	if req.CreatedFrom != nil {
+		flt.add("created_at >= " + flt.arg(*req.CreatedFrom))
+	}
+	if req.CreatedTo != nil {
+		flt.add("created_at <= " + flt.arg(*req.CreatedTo))
+	}
+	if req.UpdatedFrom != nil {
+		flt.add("updated_at >= " + flt.arg(*req.UpdatedFrom))
+	}
+	if req.UpdatedTo != nil {
+		flt.add("updated_at <= " + flt.arg(*req.UpdatedTo))
+	}
+
+	sort, found := houseSortColumns[req.SortBy]
+	if !found {
+		sort = houseSortColumns[models.HouseSortID]
+	}
+	flt.keyset(sort.column, "id", sort.cast, req.Order, req.After)
+
+	query := `SELECT ` + houseColumns + ` FROM houses` + flt.where() + orderBy(sort.column, "id", req.Order) +
+		` LIMIT ` + flt.arg(req.Limit+1)
+
+	rows, err := f.conn.PC.Query(ctx, query, flt.args...)
+	if err != nil {
+		return nil, NewError("can't get list of houses", err)
+	}
+	defer rows.Close()
+	var houses []models.House
+	for rows.Next() {
+		house, err := scanHouse(rows)
+		if err != nil {
+			return nil, NewError("can't scan house", err)
+		}
+		houses = append(houses, house)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't get list of houses", err)
+	}
+
+	return houses, nil
+}
+
+func (f HouseStorage) Update(ctx context.Context, req models.HouseUpdateRequest) (models.HouseUpdateResponse, repository.DatabaseError) {
+	query := `UPDATE houses SET address = COALESCE($2, address), year = COALESCE($3, year), developer = COALESCE($4, developer),
+		floors = COALESCE($5, floors), building_type = COALESCE($6, building_type), latitude = COALESCE($7, latitude),
+		longitude = COALESCE($8, longitude), amenities = COALESCE($9, amenities), developer_id = COALESCE($10, developer_id),
+		address_normalized = COALESCE($11, address_normalized), updated_at = NOW()
+	WHERE id = $1 RETURNING ` + houseColumns
+
+	house, err := scanHouse(f.conn.PC.QueryRow(ctx, query, req.ID, req.Address, req.Year, req.Developer, req.Floors,
+		req.BuildingType, req.Latitude, req.Longitude, req.Amenities, req.DeveloperID, req.AddressNormalized))
+	if err != nil {
+		s := fmt.Sprintf("can't update house %d", req.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.HouseUpdateResponse{}, NewError(s, repository.ErrEntityNotFound)
+		}
+		return models.HouseUpdateResponse{}, houseQueryError(s, err)
+	}
+
+	return models.HouseUpdateResponse{
+		House: house,
+	}, nil
+}
+
+// approvedFlatsColumn counts approved flats of the house
+const approvedFlatsColumn = `(SELECT COUNT(*) FROM flats WHERE flats.house_id = houses.id AND flats.status = 'approved') AS approved_flats`
+
+// distanceExpression calculates distance in meters from the point by haversine formula, placeholders are for latitude and longitude
+const distanceExpression = `2 * %[1]f * ASIN(SQRT(LEAST(1, POWER(SIN(RADIANS(latitude - %[2]s) / 2), 2) +
+	COS(RADIANS(%[2]s)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - %[3]s) / 2), 2))))`
+
+// addBox adds condition of the house being inside the box
+func addBox(flt *filter, box geo.Box) {
+	flt.add("latitude BETWEEN " + flt.arg(box.MinLatitude) + " AND " + flt.arg(box.MaxLatitude))
+	op := "AND"
+	if box.CrossesAntimeridian() {
+		op = "OR"
+	}
+	flt.add(fmt.Sprintf("(longitude >= %s %s longitude <= %s)", flt.arg(box.MinLongitude), op, flt.arg(box.MaxLongitude)))
+}
+
+func (f HouseStorage) Nearby(ctx context.Context, req models.HouseNearbyRequest, user models.User) ([]models.HouseLocation, repository.DatabaseError) {
+	center := geo.Point{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	var flt filter
+	distance := fmt.Sprintf(distanceExpression, geo.EarthRadius, flt.arg(center.Latitude), flt.arg(center.Longitude))
+	addBox(&flt, geo.Around(center, *req.Radius))
+	if user.UserType == models.Client {
+		flt.add("archived_at IS NULL")
+	}
+	query := `SELECT ` + houseColumns + `, approved_flats, distance FROM (SELECT ` + houseColumns + `, ` + approvedFlatsColumn +
+		`, ` + distance + ` AS distance FROM houses` + flt.where() + `) AS houses
+	WHERE distance <= ` + flt.arg(*req.Radius) + ` ORDER BY distance, id LIMIT ` + flt.arg(req.Limit)
+
+	houses, err := f.locations(ctx, query, flt.args, true)
+	if err != nil {
+		return nil, NewError("can't get houses nearby", err)
+	}
+
+	return houses, nil
+}
+
+func (f HouseStorage) Within(ctx context.Context, req models.HouseWithinRequest, user models.User) ([]models.HouseLocation, repository.DatabaseError) {
+	var flt filter
+	addBox(&flt, geo.Box{MinLatitude: *req.MinLatitude, MinLongitude: *req.MinLongitude, MaxLatitude: *req.MaxLatitude, MaxLongitude: *req.MaxLongitude})
+	if user.UserType == models.Client {
+		flt.add("archived_at IS NULL")
+	}
+	query := `SELECT ` + houseColumns + `, ` + approvedFlatsColumn + ` FROM houses` + flt.where() + ` ORDER BY id LIMIT ` + flt.arg(req.Limit)
+
+	houses, err := f.locations(ctx, query, flt.args, false)
+	if err != nil {
+		return nil, NewError("can't get houses inside the box", err)
+	}
+
+	return houses, nil
+}
+
+// searchQuery builds tsquery text where each word of the query is matched as a prefix
+func searchQuery(text string) string {
+	tokens := address.Tokens(text)
+	for i, token := range tokens {
+		tokens[i] = token + ":*"
+	}
+	return strings.Join(tokens, " & ")
+}
+
+func (f HouseStorage) Search(ctx context.Context, req models.HouseSearchRequest, user models.User) ([]models.HouseSearchResult, repository.DatabaseError) {
+	var flt filter
+	flt.add("search_vector @@ query")
+	if user.UserType == models.Client {
+		flt.add("archived_at IS NULL")
+	}
+	query := `SELECT ` + houseColumns + `, ts_rank(search_vector, query) AS rank FROM houses, to_tsquery('russian', ` + flt.arg(searchQuery(req.Query)) + `) query` +
+		flt.where() + ` ORDER BY rank DESC, id LIMIT ` + flt.arg(req.Limit)
+
+	rows, err := f.conn.PC.Query(ctx, query, flt.args...)
+	if err != nil {
+		return nil, NewError(fmt.Sprintf("can't search houses by query %q", req.Query), err)
+	}
+	defer rows.Close()
+	var houses []models.HouseSearchResult
+	for rows.Next() {
+		var result models.HouseSearchResult
+		if result.House, err = scanHouse(rows, &result.Rank); err != nil {
+			return nil, NewError("can't scan house", err)
+		}
+		houses = append(houses, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError(fmt.Sprintf("can't search houses by query %q", req.Query), err)
+	}
+
+	return houses, nil
+}
+
+// locations selects houses with count of approved flats and optionally distance
+func (f HouseStorage) locations(ctx context.Context, query string, args []any, withDistance bool) ([]models.HouseLocation, error) {
+	rows, err := f.conn.PC.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var houses []models.HouseLocation
+	for rows.Next() {
+		var location models.HouseLocation
+		extra := []any{&location.ApprovedFlats}
+		if withDistance {
+			extra = append(extra, &location.Distance)
+		}
+		if location.House, err = scanHouse(rows, extra...); err != nil {
+			return nil, fmt.Errorf("can't scan house: %w", err)
+		}
+		houses = append(houses, location)
+	}
+
+	return houses, rows.Err()
+}
+
+func (f HouseStorage) Archive(ctx context.Context, id int) (models.House, repository.DatabaseError) {
+	query := `UPDATE houses SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL RETURNING ` + houseColumns
+
+	house, err := scanHouse(f.conn.PC.QueryRow(ctx, query, id))
+	if err == nil {
+		return house, nil
+	}
+	s := fmt.Sprintf("can't archive house %d", id)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.House{}, NewError(s, err)
+	}
+
+	var exists bool
+	if err := f.conn.PC.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM houses WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return models.House{}, NewError(s, err)
+	}
+	if exists {
+		return models.House{}, NewError(s, repository.ErrEntityArchived)
+	}
+	return models.House{}, NewError(s, repository.ErrEntityNotFound)
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ReportStorage struct {
+	conn Connection
+}
+
+var _ repository.ReportStorage = ReportStorage{}
+
+func NewReportStorage(conn Connection) ReportStorage {
+	return ReportStorage{
+		conn: conn,
+	}
+}
+
+func (r ReportStorage) FlatsPerDay(ctx context.Context, from, to time.Time) ([]models.DailyFlats, repository.DatabaseError) {
+	query := `WITH days AS (
+		SELECT generate_series(($1::timestamptz AT TIME ZONE 'UTC')::date, ($2::timestamptz AT TIME ZONE 'UTC')::date, interval '1 day')::date AS day
+	), created AS (
+		SELECT (created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) AS flats
+		FROM flats WHERE created_at BETWEEN $1 AND $2 GROUP BY 1
+	), decided AS (
+		SELECT (created_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) FILTER (WHERE status = $3) AS approved, COUNT(*) FILTER (WHERE status = $4) AS declined
+		FROM flat_moderation_events WHERE created_at BETWEEN $1 AND $2 GROUP BY 1
+	)
+	SELECT days.day, COALESCE(created.flats, 0), COALESCE(decided.approved, 0), COALESCE(decided.declined, 0)
+	FROM days LEFT JOIN created USING (day) LEFT JOIN decided USING (day) ORDER BY days.day`
+
+	rows, err := r.conn.PC.Query(ctx, query, from, to, models.Approved, models.Declined)
+	if err != nil {
+		return nil, NewError("can't get flats per day", err)
+	}
+	defer rows.Close()
+	var days []models.DailyFlats
+	for rows.Next() {
+		var (
+			day   models.DailyFlats
+			value time.Time
+		)
+		if err := rows.Scan(&value, &day.Created, &day.Approved, &day.Declined); err != nil {
+			return nil, NewError("can't scan flats per day", err)
+		}
+		day.Day = value.Format(models.ReportDayLayout)
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't get flats per day", err)
+	}
+
+	return days, nil
+}
+
+func (r ReportStorage) ModeratorThroughput(ctx context.Context, from, to time.Time) ([]models.ModeratorThroughput, repository.DatabaseError) {
+	query := `SELECT moderator_id, COUNT(*) FILTER (WHERE status = $3), COUNT(*) FILTER (WHERE status = $4), COUNT(*)
+	FROM flat_moderation_events WHERE created_at BETWEEN $1 AND $2
+	GROUP BY moderator_id ORDER BY COUNT(*) DESC, moderator_id`
+
+	rows, err := r.conn.PC.Query(ctx, query, from, to, models.Approved, models.Declined)
+	if err != nil {
+		return nil, NewError("can't get moderator throughput", err)
+	}
+	defer rows.Close()
+	var moderators []models.ModeratorThroughput
+	for rows.Next() {
+		var (
+			moderator   models.ModeratorThroughput
+			moderatorID pgtype.UUID
+		)
+		if err := rows.Scan(&moderatorID, &moderator.Approved, &moderator.Declined, &moderator.Total); err != nil {
+			return nil, NewError("can't scan moderator throughput", err)
+		}
+		if moderatorID.Valid {
+			id := uuid.UUID(moderatorID.Bytes)
+			moderator.ModeratorID = &id
+		}
+		moderators = append(moderators, moderator)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't get moderator throughput", err)
+	}
+
+	return moderators, nil
+}
+
+func (r ReportStorage) DeveloperPrices(ctx context.Context, from, to time.Time) ([]models.DeveloperPrice, repository.DatabaseError) {
+	query := `SELECT h.developer_id, COALESCE(h.developer, ''), COALESCE(h.year, 0), COUNT(*), PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY f.price)
+	FROM flats f JOIN houses h ON h.id = f.house_id
+	WHERE f.status = $3 AND (SELECT MAX(e.created_at) FROM flat_moderation_events e WHERE e.flat_id = f.id AND e.status = $3) BETWEEN $1 AND $2
+	GROUP BY h.developer_id, h.developer, h.year ORDER BY COALESCE(h.developer, ''), h.year`
+
+	rows, err := r.conn.PC.Query(ctx, query, from, to, models.Approved)
+	if err != nil {
+		return nil, NewError("can't get prices by developer", err)
+	}
+	defer rows.Close()
+	var prices []models.DeveloperPrice
+	for rows.Next() {
+		var price models.DeveloperPrice
+		if err := rows.Scan(&price.DeveloperID, &price.Developer, &price.Year, &price.Flats, &price.MedianPrice); err != nil {
+			return nil, NewError("can't scan price by developer", err)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError("can't get prices by developer", err)
+	}
+
+	return prices, nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/antsrp/house_service/internal/domain/models"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -23,48 +24,242 @@ func NewTokenStorage(conn Connection) TokenStorage {
 	}
 }
 
-func (t TokenStorage) UserByToken(ctx context.Context, token string) (models.User, repository.DatabaseError) {
-	query := `SELECT users.id, user_type FROM tokens 
-	JOIN users ON users.id = tokens.user_id
-	WHERE token = $1 AND created_at BETWEEN NOW() - INTERVAL '24 HOURS' AND NOW()`
+// IsRevoked checks if the token itself, its session or all tokens of the user issued before it are revoked
+func (t TokenStorage) IsRevoked(ctx context.Context, token models.Token) (bool, repository.DatabaseError) {
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)
+	OR EXISTS(SELECT 1 FROM sessions WHERE id = $2 AND revoked_at IS NOT NULL)
+	OR EXISTS(SELECT 1 FROM users WHERE id = $3 AND tokens_revoked_at > $4)`
 
-	var user models.User
+	var revoked bool
 
-	if err := t.conn.PC.QueryRow(ctx, query, token).Scan(&user.ID, &user.UserType); err != nil {
-		s := fmt.Sprintf("can't get user by token %s", token)
-		if errors.Is(err, pgx.ErrNoRows) {
-			return models.User{}, NewError(s, repository.ErrEntityNotFound)
-		}
-		return models.User{}, NewError(s, err)
+	if err := t.conn.PC.QueryRow(ctx, query, token.ID, token.SessionID, token.UserID, token.IssuedAt).Scan(&revoked); err != nil {
+		return false, NewError(fmt.Sprintf("can't check revocation of token %v", token.ID), err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeToken revokes the access token and its session
+func (t TokenStorage) RevokeToken(ctx context.Context, token models.Token) repository.DatabaseError {
+	tx, err := t.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`
+	if _, err := tx.Exec(ctx, query, token.ID, token.UserID, token.ExpiresAt); err != nil {
+		return NewError(fmt.Sprintf("can't revoke token %v", token.ID), err)
+	}
+
+	if err := revokeSessions(ctx, tx, `id = $1`, token.SessionID); err != nil {
+		return NewError(fmt.Sprintf("can't revoke session %v", token.SessionID), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
+
+	return nil
+}
+
+// RevokeUserTokens revokes all sessions of the user and access tokens issued before the time. The time is
+// given by the issuer of tokens, so it's compared with iat claim without skew between clocks of the app and the database
+func (t TokenStorage) RevokeUserTokens(ctx context.Context, id uuid.UUID, at time.Time) repository.DatabaseError {
+	tx, err := t.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE users SET tokens_revoked_at = $2 WHERE id = $1`
+	tag, err := tx.Exec(ctx, query, id, at)
+	if err != nil {
+		return NewError(fmt.Sprintf("can't revoke tokens of user %v", id), err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(fmt.Sprintf("can't revoke tokens of user %v", id), repository.ErrEntityNotFound)
+	}
+
+	if err := revokeSessions(ctx, tx, `user_id = $1`, id); err != nil {
+		return NewError(fmt.Sprintf("can't revoke sessions of user %v", id), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
 	}
 
-	return user, nil
+	return nil
 }
-func (t TokenStorage) TokenByID(ctx context.Context, user models.User) (string, repository.DatabaseError) {
-	query := `SELECT token FROM tokens 
-	JOIN users ON users.id = tokens.user_id
-	WHERE users.id = $1`
 
-	var token string
+const addRefreshTokenQuery = `INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+func (t TokenStorage) AddRefreshToken(ctx context.Context, token models.RefreshToken) repository.DatabaseError {
+	if _, err := t.conn.PC.Exec(ctx, addRefreshTokenQuery, token.ID, token.FamilyID, token.UserID, token.Hash, token.CreatedAt, token.ExpiresAt); err != nil {
+		return NewError(fmt.Sprintf("can't insert refresh token for user %v", token.UserID), err)
+	}
+
+	return nil
+}
+
+func (t TokenStorage) RefreshToken(ctx context.Context, hash string) (models.RefreshToken, repository.DatabaseError) {
+	query := `SELECT refresh_tokens.id, family_id, user_id, user_type, token_hash, created_at, expires_at, used_at, revoked_at FROM refresh_tokens
+	JOIN users ON users.id = refresh_tokens.user_id
+	WHERE token_hash = $1`
+
+	var token models.RefreshToken
 
-	if err := t.conn.PC.QueryRow(ctx, query, user.ID).Scan(&token); err != nil {
-		s := fmt.Sprintf("can't get token for user by id %v", user.ID)
+	if err := t.conn.PC.QueryRow(ctx, query, hash).Scan(&token.ID, &token.FamilyID, &token.UserID, &token.UserType, &token.Hash,
+		&token.CreatedAt, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", NewError(s, repository.ErrEntityNotFound)
+			return models.RefreshToken{}, NewError("can't get refresh token", repository.ErrEntityNotFound)
 		}
-		return "", NewError(s, err)
+		return models.RefreshToken{}, NewError("can't get refresh token", err)
 	}
 
 	return token, nil
 }
-func (t TokenStorage) AddToken(ctx context.Context, user models.User, token string, currentTime time.Time) repository.DatabaseError {
-	query := `INSERT INTO tokens (user_id, token, created_at) VALUES ($1, $2, $3)
-	ON CONFLICT (user_id) DO UPDATE
-	SET token = $2, created_at = $3`
 
-	if _, err := t.conn.PC.Exec(ctx, query, user.ID, token, currentTime); err != nil {
-		return NewError(fmt.Sprintf("can't insert or update token for user %v", user.ID), err)
+// RotateRefreshToken marks the token as used, updates client data, last seen and expiration time of its session
+// and adds the next token in a single transaction. Only one of concurrent calls for the same token succeeds
+func (t TokenStorage) RotateRefreshToken(ctx context.Context, used uuid.UUID, session models.Session, next models.RefreshToken) repository.DatabaseError {
+	s := fmt.Sprintf("can't rotate refresh token %v", used)
+	useQuery := `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+	touchQuery := `UPDATE sessions SET user_agent = $2, ip = $3, last_seen_at = $4, expires_at = $5 WHERE id = $1 AND revoked_at IS NULL`
+
+	tx, err := t.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, useQuery, used)
+	if err != nil {
+		return NewError(s, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(s, repository.ErrNoRowsAffected)
+	}
+	if tag, err = tx.Exec(ctx, touchQuery, session.ID, session.UserAgent, session.IP, session.LastSeenAt, session.ExpiresAt); err != nil {
+		return NewError(fmt.Sprintf("can't update session %v", session.ID), err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(fmt.Sprintf("can't update session %v", session.ID), repository.ErrEntityNotFound)
+	}
+	if _, err := tx.Exec(ctx, addRefreshTokenQuery, next.ID, next.FamilyID, next.UserID, next.Hash, next.CreatedAt, next.ExpiresAt); err != nil {
+		return NewError(fmt.Sprintf("can't insert refresh token for user %v", next.UserID), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
+
+	return nil
+}
+
+func (t TokenStorage) RevokeTokenFamily(ctx context.Context, family uuid.UUID) repository.DatabaseError {
+	tx, err := t.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError("can't begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := revokeSessions(ctx, tx, `id = $1`, family); err != nil {
+		return NewError(fmt.Sprintf("can't revoke refresh tokens of family %v", family), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
+	}
+
+	return nil
+}
+
+func (t TokenStorage) AddSession(ctx context.Context, session models.Session) repository.DatabaseError {
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := t.conn.PC.Exec(ctx, query, session.ID, session.UserID, session.UserAgent, session.IP,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt); err != nil {
+		return NewError(fmt.Sprintf("can't insert session for user %v", session.UserID), err)
+	}
+
+	return nil
+}
+
+// Sessions returns active sessions of the user, the last seen first
+func (t TokenStorage) Sessions(ctx context.Context, id uuid.UUID) ([]models.Session, repository.DatabaseError) {
+	query := `SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at FROM sessions
+	WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	ORDER BY last_seen_at DESC, id`
+
+	rows, err := t.conn.PC.Query(ctx, query, id)
+	if err != nil {
+		return nil, NewError(fmt.Sprintf("can't get sessions of user %v", id), err)
+	}
+	defer rows.Close()
+	var sessions []models.Session
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt); err != nil {
+			return nil, NewError("can't scan session", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, NewError(fmt.Sprintf("can't get sessions of user %v", id), err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes active session if it belongs to the user
+func (t TokenStorage) RevokeSession(ctx context.Context, userID, id uuid.UUID) repository.DatabaseError {
+	s := fmt.Sprintf("can't revoke session %v of user %v", id, userID)
+
+	tx, err := t.conn.PC.Begin(ctx)
+	if err != nil {
+		return NewError(s, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var found bool
+	query := `SELECT EXISTS(SELECT 1 FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL)`
+	if err := tx.QueryRow(ctx, query, id, userID).Scan(&found); err != nil {
+		return NewError(s, err)
+	}
+	if !found {
+		return NewError(s, repository.ErrEntityNotFound)
+	}
+
+	if err := revokeSessions(ctx, tx, `id = $1`, id); err != nil {
+		return NewError(s, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return NewError("can't commit transaction", err)
 	}
 
 	return nil
 }
+
+// DeleteExpiredRevoked deletes revoked tokens which can't pass validation anymore because of expiration
+func (t TokenStorage) DeleteExpiredRevoked(ctx context.Context, before time.Time) (int, repository.DatabaseError) {
+	tag, err := t.conn.PC.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, NewError("can't delete expired revoked tokens", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// revokeSessions revokes sessions matching the condition and their refresh tokens
+func revokeSessions(ctx context.Context, tx pgx.Tx, cond string, args ...any) error {
+	query := fmt.Sprintf(`WITH revoked AS (
+		UPDATE sessions SET revoked_at = NOW() WHERE %s AND revoked_at IS NULL RETURNING id
+	)
+	UPDATE refresh_tokens SET revoked_at = NOW() WHERE revoked_at IS NULL AND family_id IN (SELECT id FROM revoked)`, cond)
+
+	_, err := tx.Exec(ctx, query, args...)
+	return err
+}
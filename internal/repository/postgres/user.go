@@ -7,6 +7,7 @@ import (
 
 	"github.com/antsrp/house_service/internal/domain/models"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -39,13 +40,13 @@ func (u UserStorage) Add(ctx context.Context, user models.User) (models.User, re
 
 	return result, nil
 }
-func (u UserStorage) Get(ctx context.Context, user models.User) (models.User, repository.DatabaseError) {
-	query := `SELECT id, email, user_type FROM users WHERE id = $1 AND password = $2`
+func (u UserStorage) Get(ctx context.Context, id uuid.UUID) (models.User, repository.DatabaseError) {
+	query := `SELECT id, email, password, user_type FROM users WHERE id = $1`
 
 	var result models.User
 
-	if err := u.conn.PC.QueryRow(ctx, query, user.ID, user.Password).Scan(&result.ID, &result.Email, &result.UserType); err != nil {
-		s := fmt.Sprintf("can't get user with id %v", user.ID)
+	if err := u.conn.PC.QueryRow(ctx, query, id).Scan(&result.ID, &result.Email, &result.Password, &result.UserType); err != nil {
+		s := fmt.Sprintf("can't get user with id %v", id)
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.User{}, NewError(s, repository.ErrEntityNotFound)
 		}
@@ -54,3 +55,17 @@ func (u UserStorage) Get(ctx context.Context, user models.User) (models.User, re
 
 	return result, nil
 }
+
+func (u UserStorage) UpdatePassword(ctx context.Context, id uuid.UUID, hash string) repository.DatabaseError {
+	query := `UPDATE users SET password = $2 WHERE id = $1`
+
+	tag, err := u.conn.PC.Exec(ctx, query, id, hash)
+	if err != nil {
+		return NewError(fmt.Sprintf("can't update password of user %v", id), err)
+	}
+	if tag.RowsAffected() == 0 {
+		return NewError(fmt.Sprintf("can't update password of user %v", id), repository.ErrEntityNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+)
+
+// ReportStorage aggregates flats and moderation events created inside the period, both bounds are inclusive
+type ReportStorage interface {
+	FlatsPerDay(ctx context.Context, from, to time.Time) ([]models.DailyFlats, DatabaseError) // contains every day of the period
+	ModeratorThroughput(ctx context.Context, from, to time.Time) ([]models.ModeratorThroughput, DatabaseError)
+	DeveloperPrices(ctx context.Context, from, to time.Time) ([]models.DeveloperPrice, DatabaseError) // approved flats whose last approval is inside the period
+}
@@ -5,10 +5,19 @@ import (
 	"time"
 
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/google/uuid"
 )
 
 type TokenStorage interface {
-	UserByToken(context.Context, string) (models.User, DatabaseError)
-	TokenByID(context.Context, models.User) (string, DatabaseError)
-	AddToken(context.Context, models.User, string, time.Time) DatabaseError
+	IsRevoked(context.Context, models.Token) (bool, DatabaseError)
+	RevokeToken(context.Context, models.Token) DatabaseError
+	RevokeUserTokens(context.Context, uuid.UUID, time.Time) DatabaseError
+	AddRefreshToken(context.Context, models.RefreshToken) DatabaseError
+	RefreshToken(context.Context, string) (models.RefreshToken, DatabaseError)
+	RevokeTokenFamily(context.Context, uuid.UUID) DatabaseError
+	RotateRefreshToken(context.Context, uuid.UUID, models.Session, models.RefreshToken) DatabaseError // uses the token, touches its session and adds the next one atomically
+	AddSession(context.Context, models.Session) DatabaseError
+	Sessions(context.Context, uuid.UUID) ([]models.Session, DatabaseError)
+	RevokeSession(context.Context, uuid.UUID, uuid.UUID) DatabaseError
+	DeleteExpiredRevoked(context.Context, time.Time) (int, DatabaseError) // deletes revoked access tokens expired before the time
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/google/uuid"
 )
 
 type UserStorage interface {
 	Add(context.Context, models.User) (models.User, DatabaseError)
-	Get(context.Context, models.User) (models.User, DatabaseError)
+	Get(context.Context, uuid.UUID) (models.User, DatabaseError) // returns user with hash of the password
+	UpdatePassword(ctx context.Context, id uuid.UUID, hash string) DatabaseError
 }
@@ -32,7 +32,7 @@ func newAuthHandler(logger *slog.Logger, tokenService service.TokenServicer) aut
 
 var errInternal = fmt.Errorf("internal auth error")
 
-func (h authHandler) parseToken(c *gin.Context) error {
+func (h authHandler) parseToken(c *gin.Context, ctx context.Context) error {
 	header := c.GetHeader("Authorization")
 	if header == "" {
 		return fmt.Errorf("no token provided")
@@ -52,9 +52,9 @@ func (h authHandler) parseToken(c *gin.Context) error {
 	if h.checkDummy(c, token) {
 		return nil
 	}
-	ctx := context.Background()
 
-	user, err := h.tokenService.UserByToken(ctx, token)
+	// the token is verified locally, storage is only asked whether it is revoked
+	parsed, err := h.tokenService.ParseToken(ctx, token)
 	if err != nil {
 		if err.Status() == service.Internal {
 			abort(c, ctx, h.logger, slog.LevelInfo, "cannot recognize user by token", map[string]any{"error": err.Cause()}, codeByStatus(err.Status()), err.Code())
@@ -63,7 +63,8 @@ func (h authHandler) parseToken(c *gin.Context) error {
 		return err.Cause()
 	}
 
-	setUser(c, user)
+	setUser(c, models.User{ID: parsed.UserID, UserType: parsed.UserType})
+	setAccessToken(c, parsed)
 	return nil
 
 	//return fmt.Errorf("only dummy users available for now")
@@ -92,7 +93,7 @@ func (h authHandler) checkDummy(c *gin.Context, token string) bool {
 
 func (h authHandler) authRequired(c *gin.Context) {
 	ctx := parseRequestContext(c, h.logger)
-	if err := h.parseToken(c); err != nil {
+	if err := h.parseToken(c, ctx); err != nil {
 		if !errors.Is(err, errInternal) {
 			abort(c, ctx, h.logger, slog.LevelInfo, "user is not authorized", map[string]any{"error": err.Error()}, http.StatusUnauthorized)
 		}
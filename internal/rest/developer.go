@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxDeveloperNameLength = 50
+
+// checkDeveloperName validates name of developer, returns description of the problem if there is any
+func checkDeveloperName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "name of developer is not provided"
+	}
+	if len([]rune(name)) > maxDeveloperNameLength {
+		return "name of developer is too long"
+	}
+	return ""
+}
+
+func (h Handler) developerList(c *gin.Context) { // GET /developer
+	ctx := parseRequestContext(c, h.logger)
+
+	developers, srvErr := h.developerService.Developers(ctx)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, developers)
+}
+
+func (h Handler) developerCreate(c *gin.Context) { // POST /developer/create
+	ctx := parseRequestContext(c, h.logger)
+	var req models.DeveloperCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request data", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if msg := checkDeveloperName(req.Name); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+
+	developer, srvErr := h.developerService.CreateDeveloper(ctx, req)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, developer)
+}
+
+func (h Handler) developerByID(c *gin.Context) { // GET /developer/{id}
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of developer")
+		return
+	}
+
+	developer, srvErr := h.developerService.Developer(ctx, id)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, developer)
+}
+
+func (h Handler) developerHouses(c *gin.Context) { // GET /developer/{id}/houses
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of developer")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	var req models.HouseListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if msg := checkPage(req.PageRequest, models.HouseSortFields); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	if req.YearFrom != nil && req.YearTo != nil && *req.YearFrom > *req.YearTo {
+		abort(c, ctx, h.logger, slog.LevelInfo, "year range is unacceptable", nil, http.StatusBadRequest)
+		return
+	}
+
+	houses, srvErr := h.developerService.DeveloperHouses(ctx, id, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, houses)
+}
+
+func (h Handler) developerUpdate(c *gin.Context) { // POST /developer/{id}/update
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of developer")
+		return
+	}
+
+	var req models.DeveloperUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request data", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.Name == nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "nothing to update", nil, http.StatusBadRequest)
+		return
+	}
+	if msg := checkDeveloperName(*req.Name); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	req.ID = id
+
+	developer, srvErr := h.developerService.UpdateDeveloper(ctx, req)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, developer)
+}
+
+func (h Handler) developerDelete(c *gin.Context) { // POST /developer/{id}/delete
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of developer")
+		return
+	}
+
+	if srvErr := h.developerService.DeleteDeveloper(ctx, id); srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
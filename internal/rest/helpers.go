@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/antsrp/house_service/internal/domain/models"
@@ -41,6 +42,17 @@ func abort(c *gin.Context, ctx context.Context, logger *slog.Logger, level slog.
 	c.AbortWithStatus(status)
 }
 
+// abortDuplicateHouse responds with conflict pointing at the existing house if error is caused by duplicate address
+func abortDuplicateHouse(c *gin.Context, ctx context.Context, logger *slog.Logger, err service.Error) bool {
+	var duplicate *service.DuplicateHouseError
+	if !errors.As(err.Cause(), &duplicate) {
+		return false
+	}
+	logger.InfoContext(ctx, duplicate.Error())
+	c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": duplicate.Error(), "house_id": duplicate.ID})
+	return true
+}
+
 func parseRequestContext(c *gin.Context, logger *slog.Logger) context.Context {
 	val, ok := c.Get(requestContextKey)
 	if !ok {
@@ -60,6 +72,8 @@ func codeByStatus(status service.ErrorStatus) int {
 	switch status {
 	case service.Conflict:
 		code = http.StatusConflict
+	case service.Forbidden:
+		code = http.StatusForbidden
 	case service.Internal:
 		code = http.StatusInternalServerError
 	case service.BadRequest:
@@ -100,9 +114,13 @@ func paramIntErrorHandler(c *gin.Context, ctx context.Context, logger *slog.Logg
 var (
 	errTagNotExist       = fmt.Errorf("user tag not exist")
 	errTagDataNoUserType = fmt.Errorf("user tag data is not user type")
+	errDummyToken        = fmt.Errorf("dummy token has no session")
 )
 
-const authusertag = "auth-user-tag-data"
+const (
+	authusertag  = "auth-user-tag-data"
+	authtokentag = "auth-token-tag-data"
+)
 
 func setUser(c *gin.Context, user models.User) {
 	c.Set(authusertag, user)
@@ -120,6 +138,23 @@ func parseUser(c *gin.Context) (models.User, error) {
 	return user, nil
 }
 
+func setAccessToken(c *gin.Context, token models.Token) {
+	c.Set(authtokentag, token)
+}
+
+// parseAccessToken returns token the request is authorized with, dummy tokens are not stored
+func parseAccessToken(c *gin.Context) (models.Token, error) {
+	data, exists := c.Get(authtokentag)
+	if !exists {
+		return models.Token{}, errDummyToken
+	}
+	token, ok := data.(models.Token)
+	if !ok {
+		return models.Token{}, errDummyToken
+	}
+	return token, nil
+}
+
 func parseUserErrorHandler(c *gin.Context, ctx context.Context, logger *slog.Logger, err error) {
 	if errors.Is(err, errNotProvided) {
 		abort(c, ctx, logger, slog.LevelInfo, "id of house is not provided", nil, http.StatusBadRequest)
@@ -129,3 +164,84 @@ func parseUserErrorHandler(c *gin.Context, ctx context.Context, logger *slog.Log
 		abort(c, ctx, logger, slog.LevelError, fmt.Sprintf("cannot parse id parameter: %s", err.Error()), nil, http.StatusInternalServerError)
 	}
 }
+
+// checkPage validates pagination parameters of request, returns description of the problem if there is any
+func checkPage(page models.PageRequest, sortFields []string) string {
+	if page.SortBy != "" && !slices.Contains(sortFields, page.SortBy) {
+		return fmt.Sprintf("sort_by value is unacceptable, possible values: %v", sortFields)
+	}
+	if page.Order != "" && page.Order != models.Asc && page.Order != models.Desc {
+		return "order value is unacceptable"
+	}
+	if page.Limit < 0 || page.Limit > models.MaxPageLimit {
+		return fmt.Sprintf("limit value should be between 0 and %d", models.MaxPageLimit)
+	}
+	return ""
+}
+
+// checkFlatCreate validates request of flat creation, returns description of the problem if there is any
+func checkFlatCreate(req models.FlatCreateRequest) string {
+	if req.HouseID < 1 {
+		return "house_id field is not set or value is inappropriate"
+	}
+	if req.Price == nil || *req.Price < 0 {
+		return "price field is not set or value is inappropriate"
+	}
+	if req.Room < 1 {
+		return "room field is not set or value is inappropriate"
+	}
+	if req.Number < 0 {
+		return "number value is inappropriate"
+	}
+	return checkFlatAttributes(nil, req.TotalArea, req.LivingArea)
+}
+
+// checkFlatAttributes validates optional attributes of flat, returns description of the problem if there is any
+func checkFlatAttributes(number *int, totalArea, livingArea *float64) string {
+	if number != nil && *number < 1 {
+		return "number value is inappropriate"
+	}
+	if totalArea != nil && *totalArea <= 0 {
+		return "total_area value is inappropriate"
+	}
+	if livingArea != nil && *livingArea <= 0 {
+		return "living_area value is inappropriate"
+	}
+	if totalArea != nil && livingArea != nil && *livingArea > *totalArea {
+		return "living_area can't be greater than total_area"
+	}
+	return ""
+}
+
+// checkHouseAttributes validates optional attributes of house, returns description of the problem if there is any
+func checkHouseAttributes(floors *int, buildingType *models.BuildingType, latitude, longitude *float64, amenities []models.Amenity) string {
+	if floors != nil && *floors < 1 {
+		return "floors value is unacceptable"
+	}
+	if buildingType != nil && !slices.Contains(models.BuildingTypes, *buildingType) {
+		return fmt.Sprintf("building_type value is unacceptable, possible values: %v", models.BuildingTypes)
+	}
+	if (latitude == nil) != (longitude == nil) {
+		return "latitude and longitude should be provided together"
+	}
+	if msg := checkPoint(latitude, longitude, "latitude", "longitude"); msg != "" {
+		return msg
+	}
+	for _, amenity := range amenities {
+		if !slices.Contains(models.Amenities, amenity) {
+			return fmt.Sprintf("amenity %q is unknown, possible values: %v", amenity, models.Amenities)
+		}
+	}
+	return ""
+}
+
+// checkPoint validates coordinates of point if they are provided, names are used in description of the problem
+func checkPoint(latitude, longitude *float64, latitudeName, longitudeName string) string {
+	if latitude != nil && (*latitude < -90 || *latitude > 90) {
+		return latitudeName + " value is unacceptable"
+	}
+	if longitude != nil && (*longitude < -180 || *longitude > 180) {
+		return longitudeName + " value is unacceptable"
+	}
+	return ""
+}
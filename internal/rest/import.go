@@ -0,0 +1,203 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/gin-gonic/gin"
+)
+
+const maxFlatImportSize = 4 << 20 // bytes
+
+var errTooManyRows = fmt.Errorf("file contains more than %d rows", models.MaxFlatImportRows)
+
+// importFormat detects format of imported file by format parameter or by content type
+func importFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return models.FlatImportCSV
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return models.FlatImportJSONL
+	}
+	return ""
+}
+
+// parseFlatsCSV reads flats from CSV file with header, columns price and room are required
+func parseFlatsCSV(r io.Reader) ([]models.FlatImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("can't read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"price", "room"} {
+		if _, found := columns[name]; !found {
+			return nil, fmt.Errorf("column %s is missing", name)
+		}
+	}
+
+	var rows []models.FlatImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) == models.MaxFlatImportRows {
+			return nil, errTooManyRows
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) { // malformed line is rejected, the rest of the file is still imported
+			rows = append(rows, models.FlatImportRow{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := models.FlatImportRow{Line: line}
+		row.Flat, row.Error = flatFromRecord(record, columns)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// flatFromRecord converts CSV record to request, empty values of optional columns are left unset
+func flatFromRecord(record []string, columns map[string]int) (models.FlatCreateRequest, string) {
+	value := func(name string) string {
+		i, found := columns[name]
+		if !found || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	var req models.FlatCreateRequest
+	for _, field := range []struct {
+		name string
+		dest *int
+	}{{"number", &req.Number}, {"room", &req.Room}} {
+		if s := value(field.name); s != "" {
+			val, err := strconv.Atoi(s)
+			if err != nil {
+				return req, field.name + " value is not an integer"
+			}
+			*field.dest = val
+		}
+	}
+	for _, field := range []struct {
+		name string
+		dest **int
+	}{{"price", &req.Price}, {"floor", &req.Floor}} {
+		if s := value(field.name); s != "" {
+			val, err := strconv.Atoi(s)
+			if err != nil {
+				return req, field.name + " value is not an integer"
+			}
+			*field.dest = &val
+		}
+	}
+	for _, field := range []struct {
+		name string
+		dest **float64
+	}{{"total_area", &req.TotalArea}, {"living_area", &req.LivingArea}} {
+		if s := value(field.name); s != "" {
+			val, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return req, field.name + " value is not a number"
+			}
+			*field.dest = &val
+		}
+	}
+	return req, ""
+}
+
+// parseFlatsJSONL reads flats from JSON Lines file, empty lines are skipped
+func parseFlatsJSONL(r io.Reader) ([]models.FlatImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxFlatImportSize) // a line can take the whole file
+	var rows []models.FlatImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(rows) == models.MaxFlatImportRows {
+			return nil, errTooManyRows
+		}
+		row := models.FlatImportRow{Line: line}
+		if err := json.Unmarshal(data, &row.Flat); err != nil {
+			row.Error = "cannot parse line: " + err.Error()
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+func (h Handler) flatImport(c *gin.Context) { // POST /house/{id}/flats/import
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of house")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	format := importFormat(c)
+	if !slices.Contains(models.FlatImportFormats, format) {
+		abort(c, ctx, h.logger, slog.LevelInfo, fmt.Sprintf("format of file is unknown, possible values: %v", models.FlatImportFormats), nil, http.StatusBadRequest)
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxFlatImportSize)
+	var rows []models.FlatImportRow
+	if format == models.FlatImportCSV {
+		rows, err = parseFlatsCSV(body)
+	} else {
+		rows, err = parseFlatsJSONL(body)
+	}
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse file", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if len(rows) == 0 {
+		abort(c, ctx, h.logger, slog.LevelInfo, "file contains no flats", nil, http.StatusBadRequest)
+		return
+	}
+	for i := range rows {
+		rows[i].Flat.HouseID, rows[i].Flat.CreatedBy = id, user.ID
+		if rows[i].Error == "" {
+			rows[i].Error = checkFlatCreate(rows[i].Flat)
+		}
+	}
+
+	report, srvErr := h.houseFlatService.ImportFlats(ctx, id, rows)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/gin-gonic/gin"
+)
+
+// parseReportRequest binds parameters of report, aborts request and returns false if they are invalid
+func (h Handler) parseReportRequest(c *gin.Context) (models.ReportRequest, bool) {
+	ctx := parseRequestContext(c, h.logger)
+	var req models.ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return req, false
+	}
+	if req.Format != "" && !slices.Contains(models.ReportFormats, req.Format) {
+		abort(c, ctx, h.logger, slog.LevelInfo, fmt.Sprintf("format value is unacceptable, possible values: %v", models.ReportFormats), nil, http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// writeReport responds with report as JSON or as CSV file with the header and records
+func writeReport(c *gin.Context, format, name string, report any, header []string, records [][]string) {
+	if format != models.ReportFormatCSV {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(header)
+	_ = w.WriteAll(records) // headers are already sent, so error can't be reported to the client
+}
+
+func (h Handler) reportFlats(c *gin.Context) { // GET /reports/flats
+	ctx := parseRequestContext(c, h.logger)
+	req, ok := h.parseReportRequest(c)
+	if !ok {
+		return
+	}
+
+	report, srvErr := h.reportService.FlatsPerDay(ctx, req)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	records := make([][]string, 0, len(report.Days))
+	for _, day := range report.Days {
+		records = append(records, []string{day.Day, strconv.Itoa(day.Created), strconv.Itoa(day.Approved), strconv.Itoa(day.Declined)})
+	}
+	writeReport(c, req.Format, "flats", report, []string{"day", "created", "approved", "declined"}, records)
+}
+
+func (h Handler) reportModerators(c *gin.Context) { // GET /reports/moderators
+	ctx := parseRequestContext(c, h.logger)
+	req, ok := h.parseReportRequest(c)
+	if !ok {
+		return
+	}
+
+	report, srvErr := h.reportService.ModeratorThroughput(ctx, req)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	records := make([][]string, 0, len(report.Moderators))
+	for _, moderator := range report.Moderators {
+		var id string
+		if moderator.ModeratorID != nil {
+			id = moderator.ModeratorID.String()
+		}
+		records = append(records, []string{id, strconv.Itoa(moderator.Approved), strconv.Itoa(moderator.Declined), strconv.Itoa(moderator.Total)})
+	}
+	writeReport(c, req.Format, "moderators", report, []string{"moderator_id", "approved", "declined", "total"}, records)
+}
+
+func (h Handler) reportPrices(c *gin.Context) { // GET /reports/prices
+	ctx := parseRequestContext(c, h.logger)
+	req, ok := h.parseReportRequest(c)
+	if !ok {
+		return
+	}
+
+	report, srvErr := h.reportService.DeveloperPrices(ctx, req)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	records := make([][]string, 0, len(report.Prices))
+	for _, price := range report.Prices {
+		var id string
+		if price.DeveloperID != nil {
+			id = strconv.Itoa(*price.DeveloperID)
+		}
+		records = append(records, []string{id, price.Developer, strconv.Itoa(price.Year), strconv.Itoa(price.Flats),
+			strconv.FormatFloat(price.MedianPrice, 'f', -1, 64)})
+	}
+	writeReport(c, req.Format, "prices", report, []string{"developer_id", "developer", "year", "flats", "median_price"}, records)
+}
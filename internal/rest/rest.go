@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/domain/moderation"
 	"github.com/antsrp/house_service/internal/service"
 	rs "github.com/antsrp/house_service/pkg/infrastructure/rest"
 	"github.com/antsrp/house_service/pkg/log"
@@ -21,21 +23,31 @@ type Handler struct {
 	logger           *slog.Logger
 	authHandler      authHandler
 	houseFlatService service.HouseFlatServicer
+	developerService service.DeveloperServicer
+	reportService    service.ReportServicer
 	userService      service.UserServicer
+	tokenService     service.TokenServicer
 }
 
-func NewHandler(logger *slog.Logger, settings rs.Settings, hfService service.HouseFlatServicer, userService service.UserServicer, tokenService service.TokenServicer) Handler {
+func NewHandler(logger *slog.Logger, settings rs.Settings, hfService service.HouseFlatServicer, developerService service.DeveloperServicer,
+	reportService service.ReportServicer, userService service.UserServicer, tokenService service.TokenServicer) (Handler, error) {
 	h := Handler{
 		logger:           logger,
 		engine:           gin.Default(),
 		settings:         settings,
 		authHandler:      newAuthHandler(logger, tokenService),
 		houseFlatService: hfService,
+		developerService: developerService,
+		reportService:    reportService,
 		userService:      userService,
+		tokenService:     tokenService,
+	}
+	if err := h.engine.SetTrustedProxies(settings.TrustedProxies); err != nil {
+		return Handler{}, fmt.Errorf("invalid trusted proxies: %w", err)
 	}
 	h.routes()
 
-	return h
+	return h, nil
 }
 
 func (h Handler) provideRequestID(c *gin.Context) {
@@ -55,12 +67,49 @@ func (h Handler) routes() {
 	group.GET("/dummyLogin", h.dummyLogin)
 	group.POST("/login", h.login)
 	group.POST("/register", h.register)
+	group.POST("/token/refresh", h.tokenRefresh)
 	houseGroup, flatGroup := group.Group("/house", h.authHandler.authRequired), group.Group("/flat", h.authHandler.authRequired)
+	houseGroup.GET("", h.houseList)
 	houseGroup.POST("/create", h.authHandler.moderatorAuthRequired, h.houseCreate)
+	houseGroup.GET("/nearby", h.houseNearby)
+	houseGroup.GET("/within", h.houseWithin)
+	houseGroup.GET("/search", h.houseSearch)
 	houseGroup.GET("/:id", h.houseByID)
+	houseGroup.GET("/:id/stats", h.houseStats)
+	houseGroup.POST("/:id/update", h.authHandler.moderatorAuthRequired, h.houseUpdate)
+	houseGroup.POST("/:id/archive", h.authHandler.moderatorAuthRequired, h.houseArchive)
 	houseGroup.POST("/:id/subscribe", h.subscribe)
+	houseGroup.POST("/:id/flats/import", h.authHandler.moderatorAuthRequired, h.flatImport)
+	flatGroup.GET("", h.flatList)
 	flatGroup.POST("/create", h.flatCreate)
 	flatGroup.POST("/update", h.authHandler.moderatorAuthRequired, h.flatUpdate)
+	flatGroup.GET("/:id", h.flatGet)
+	flatGroup.GET("/:id/history", h.authHandler.moderatorAuthRequired, h.flatHistory)
+	flatGroup.POST("/:id/withdraw", h.flatWithdraw)
+	flatGroup.POST("/:id/resubmit", h.flatResubmit)
+	flatGroup.POST("/:id/moderation/start", h.authHandler.moderatorAuthRequired, h.moderationStart)
+	flatGroup.POST("/:id/moderation/finish", h.authHandler.moderatorAuthRequired, h.moderationFinish)
+	developerGroup := group.Group("/developer", h.authHandler.authRequired)
+	developerGroup.GET("", h.developerList)
+	developerGroup.POST("/create", h.authHandler.moderatorAuthRequired, h.developerCreate)
+	developerGroup.GET("/:id", h.developerByID)
+	developerGroup.GET("/:id/houses", h.developerHouses)
+	developerGroup.POST("/:id/update", h.authHandler.moderatorAuthRequired, h.developerUpdate)
+	developerGroup.POST("/:id/delete", h.authHandler.moderatorAuthRequired, h.developerDelete)
+	meGroup := group.Group("/me", h.authHandler.authRequired)
+	meGroup.GET("/flats", h.myFlats)
+	meGroup.GET("/sessions", h.mySessions)
+	meGroup.DELETE("/sessions/:id", h.mySessionDelete)
+	logoutGroup := group.Group("/logout", h.authHandler.authRequired)
+	logoutGroup.POST("", h.logout)
+	logoutGroup.POST("/all", h.logoutAll)
+	group.POST("/user/:id/logout", h.authHandler.authRequired, h.authHandler.moderatorAuthRequired, h.userLogout)
+	moderationGroup := group.Group("/moderation", h.authHandler.authRequired, h.authHandler.moderatorAuthRequired)
+	moderationGroup.GET("/queue", h.moderationQueue)
+	reportGroup := group.Group("/reports", h.authHandler.authRequired, h.authHandler.moderatorAuthRequired)
+	reportGroup.GET("/flats", h.reportFlats)
+	reportGroup.GET("/moderators", h.reportModerators)
+	reportGroup.GET("/prices", h.reportPrices)
 }
 
 func (h Handler) Run() error {
@@ -113,6 +162,7 @@ func (h Handler) login(c *gin.Context) { // POST /login
 		return
 	}
 
+	req.Client = clientInfo(c)
 	resp, err := h.userService.Login(ctx, req)
 	if err != nil {
 		abort(c, ctx, h.logger, slog.LevelInfo, "cannot login user", map[string]any{"error": err.Cause()}, codeByStatus(err.Status()), err.Code())
@@ -122,6 +172,34 @@ func (h Handler) login(c *gin.Context) { // POST /login
 	c.JSON(http.StatusOK, resp)
 }
 
+func (h Handler) tokenRefresh(c *gin.Context) { // POST /token/refresh
+	ctx := parseRequestContext(c, h.logger)
+
+	var req models.TokenRefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request data", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if req.RefreshToken == nil || *req.RefreshToken == "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, "refresh token is not provided", nil, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.tokenService.Refresh(ctx, *req.RefreshToken, clientInfo(c))
+	if err != nil {
+		code := http.StatusUnauthorized
+		if err.Status() == service.Internal {
+			code = http.StatusInternalServerError
+		}
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot refresh token", map[string]any{"error": err.Cause()}, code, err.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h Handler) register(c *gin.Context) { // POST /register
 	ctx := parseRequestContext(c, h.logger)
 
@@ -175,9 +253,16 @@ func (h Handler) houseCreate(c *gin.Context) { // POST /house/create
 		abort(c, ctx, h.logger, slog.LevelInfo, "year value is unacceptable", nil, http.StatusBadRequest)
 		return
 	}
+	if msg := checkHouseAttributes(req.Floors, req.BuildingType, req.Latitude, req.Longitude, req.Amenities); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
 
 	house, err := h.houseFlatService.CreateHouse(ctx, req)
 	if err != nil {
+		if abortDuplicateHouse(c, ctx, h.logger, err) {
+			return
+		}
 		abort(c, ctx, h.logger, slog.LevelInfo, err.Cause().Error(), nil, codeByStatus(err.Status()), err.Code())
 		return
 	}
@@ -185,6 +270,36 @@ func (h Handler) houseCreate(c *gin.Context) { // POST /house/create
 	c.JSON(http.StatusOK, house)
 }
 
+func (h Handler) houseList(c *gin.Context) { // GET /house
+	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+	var req models.HouseListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if msg := checkPage(req.PageRequest, models.HouseSortFields); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	if req.YearFrom != nil && req.YearTo != nil && *req.YearFrom > *req.YearTo {
+		abort(c, ctx, h.logger, slog.LevelInfo, "year range is unacceptable", nil, http.StatusBadRequest)
+		return
+	}
+
+	houses, srvErr := h.houseFlatService.Houses(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, houses)
+}
+
 func (h Handler) houseByID(c *gin.Context) { // GET /house/{id}
 	ctx := parseRequestContext(c, h.logger)
 	id, err := paramInt(c, "id")
@@ -207,6 +322,198 @@ func (h Handler) houseByID(c *gin.Context) { // GET /house/{id}
 	c.JSON(http.StatusOK, flats)
 }
 
+func (h Handler) houseStats(c *gin.Context) { // GET /house/{id}/stats
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of house")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	stats, srvErr := h.houseFlatService.HouseStats(ctx, id, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h Handler) houseNearby(c *gin.Context) { // GET /house/nearby
+	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	var req models.HouseNearbyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.Latitude == nil || req.Longitude == nil || req.Radius == nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "lat, lon and radius should be provided", nil, http.StatusBadRequest)
+		return
+	}
+	if msg := checkPoint(req.Latitude, req.Longitude, "lat", "lon"); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	if *req.Radius <= 0 || *req.Radius > models.MaxNearbyRadius {
+		abort(c, ctx, h.logger, slog.LevelInfo, fmt.Sprintf("radius value should be between 0 and %d meters", models.MaxNearbyRadius), nil, http.StatusBadRequest)
+		return
+	}
+	if req.Limit < 0 || req.Limit > models.MaxPageLimit {
+		abort(c, ctx, h.logger, slog.LevelInfo, fmt.Sprintf("limit value should be between 0 and %d", models.MaxPageLimit), nil, http.StatusBadRequest)
+		return
+	}
+
+	houses, srvErr := h.houseFlatService.HousesNearby(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, houses)
+}
+
+func (h Handler) houseWithin(c *gin.Context) { // GET /house/within
+	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	var req models.HouseWithinRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.MinLatitude == nil || req.MinLongitude == nil || req.MaxLatitude == nil || req.MaxLongitude == nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "min_lat, min_lon, max_lat and max_lon should be provided", nil, http.StatusBadRequest)
+		return
+	}
+	for _, msg := range []string{
+		checkPoint(req.MinLatitude, req.MinLongitude, "min_lat", "min_lon"),
+		checkPoint(req.MaxLatitude, req.MaxLongitude, "max_lat", "max_lon"),
+	} {
+		if msg != "" {
+			abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+			return
+		}
+	}
+	if *req.MinLatitude > *req.MaxLatitude {
+		abort(c, ctx, h.logger, slog.LevelInfo, "min_lat can't be greater than max_lat", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Limit < 0 || req.Limit > models.MaxPageLimit {
+		abort(c, ctx, h.logger, slog.LevelInfo, fmt.Sprintf("limit value should be between 0 and %d", models.MaxPageLimit), nil, http.StatusBadRequest)
+		return
+	}
+
+	houses, srvErr := h.houseFlatService.HousesWithin(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, houses)
+}
+
+func (h Handler) houseSearch(c *gin.Context) { // GET /house/search
+	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	var req models.HouseSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, "q is not provided", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Limit < 0 || req.Limit > models.MaxPageLimit {
+		abort(c, ctx, h.logger, slog.LevelInfo, fmt.Sprintf("limit value should be between 0 and %d", models.MaxPageLimit), nil, http.StatusBadRequest)
+		return
+	}
+
+	houses, srvErr := h.houseFlatService.SearchHouses(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, houses)
+}
+
+func (h Handler) houseUpdate(c *gin.Context) { // POST /house/{id}/update
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of house")
+		return
+	}
+
+	var req models.HouseUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request data", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.Address != nil && *req.Address == "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, "address for house is empty", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Year != nil && *req.Year < 0 {
+		abort(c, ctx, h.logger, slog.LevelInfo, "year value is unacceptable", nil, http.StatusBadRequest)
+		return
+	}
+	if msg := checkHouseAttributes(req.Floors, req.BuildingType, req.Latitude, req.Longitude, req.Amenities); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	req.ID = id
+
+	house, srvErr := h.houseFlatService.UpdateHouse(ctx, req)
+	if srvErr != nil {
+		if abortDuplicateHouse(c, ctx, h.logger, srvErr) {
+			return
+		}
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, house)
+}
+
+func (h Handler) houseArchive(c *gin.Context) { // POST /house/{id}/archive
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of house")
+		return
+	}
+
+	house, srvErr := h.houseFlatService.ArchiveHouse(ctx, id)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, house)
+}
+
 func (h Handler) subscribe(c *gin.Context) { // POST /house/{id}/subscribe
 	ctx := parseRequestContext(c, h.logger)
 	id, err := paramInt(c, "id")
@@ -233,6 +540,11 @@ func (h Handler) subscribe(c *gin.Context) { // POST /house/{id}/subscribe
 
 func (h Handler) flatCreate(c *gin.Context) { // POST /flat/create
 	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
 
 	var req models.FlatCreateRequest
 
@@ -241,28 +553,86 @@ func (h Handler) flatCreate(c *gin.Context) { // POST /flat/create
 		return
 	}
 
-	if req.HouseID < 1 {
-		abort(c, ctx, h.logger, slog.LevelInfo, "house_id field is not set or value is inappropriate", nil, http.StatusBadRequest)
+	if msg := checkFlatCreate(req); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
 		return
 	}
 
-	if req.Price == nil || *req.Price < 0 {
-		abort(c, ctx, h.logger, slog.LevelInfo, "price field is not set or value is inappropriate", nil, http.StatusBadRequest)
+	req.CreatedBy = user.ID
+
+	flat, srvErr := h.houseFlatService.CreateFlat(ctx, req)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
 		return
 	}
 
-	if req.Room < 1 {
-		abort(c, ctx, h.logger, slog.LevelInfo, "room field is not set or value is inappropriate", nil, http.StatusBadRequest)
+	c.JSON(http.StatusOK, flat)
+}
+
+func (h Handler) flatList(c *gin.Context) { // GET /flat
+	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
 		return
 	}
 
-	flat, err := h.houseFlatService.CreateFlat(ctx, req)
+	var req models.FlatListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if msg := checkPage(req.PageRequest, models.FlatSortFields); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	if req.Status != nil && user.UserType != models.Moderator {
+		abort(c, ctx, h.logger, slog.LevelInfo, "filter by status is available only for moderators", nil, http.StatusForbidden)
+		return
+	}
+	if req.PriceFrom != nil && req.PriceTo != nil && *req.PriceFrom > *req.PriceTo {
+		abort(c, ctx, h.logger, slog.LevelInfo, "price range is unacceptable", nil, http.StatusBadRequest)
+		return
+	}
+
+	flats, srvErr := h.houseFlatService.FlatsList(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flats)
+}
+
+func (h Handler) myFlats(c *gin.Context) { // GET /me/flats
+	ctx := parseRequestContext(c, h.logger)
+	user, err := parseUser(c)
 	if err != nil {
-		abort(c, ctx, h.logger, slog.LevelInfo, err.Cause().Error(), nil, codeByStatus(err.Status()), err.Code())
+		parseUserErrorHandler(c, ctx, h.logger, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, flat)
+	var req models.FlatListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if msg := checkPage(req.PageRequest, models.FlatSortFields); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	if req.PriceFrom != nil && req.PriceTo != nil && *req.PriceFrom > *req.PriceTo {
+		abort(c, ctx, h.logger, slog.LevelInfo, "price range is unacceptable", nil, http.StatusBadRequest)
+		return
+	}
+
+	flats, srvErr := h.houseFlatService.UserFlats(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flats)
 }
 
 func (h Handler) flatUpdate(c *gin.Context) { // POST /flat/update
@@ -290,11 +660,222 @@ func (h Handler) flatUpdate(c *gin.Context) { // POST /flat/update
 		return
 	}
 
-	flat, err := h.houseFlatService.UpdateFlat(ctx, req)
+	if req.Status != nil && !moderation.IsDecision(*req.Status) {
+		abort(c, ctx, h.logger, slog.LevelInfo, "status value is inappropriate", nil, http.StatusBadRequest)
+		return
+	}
+
+	if msg := checkFlatAttributes(req.Number, req.TotalArea, req.LivingArea); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+
+	user, err := parseUser(c)
 	if err != nil {
-		abort(c, ctx, h.logger, slog.LevelInfo, err.Cause().Error(), nil, codeByStatus(err.Status()), err.Code())
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	flat, srvErr := h.houseFlatService.UpdateFlat(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flat)
+}
+
+func (h Handler) flatGet(c *gin.Context) { // GET /flat/{id}
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of flat")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	flat, srvErr := h.houseFlatService.GetFlat(ctx, id, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
 		return
 	}
 
 	c.JSON(http.StatusOK, flat)
 }
+
+func (h Handler) flatHistory(c *gin.Context) { // GET /flat/{id}/history
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of flat")
+		return
+	}
+
+	history, srvErr := h.houseFlatService.FlatPriceHistory(ctx, id)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h Handler) flatWithdraw(c *gin.Context) { // POST /flat/{id}/withdraw
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of flat")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	flat, srvErr := h.houseFlatService.WithdrawFlat(ctx, id, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flat)
+}
+
+func (h Handler) flatResubmit(c *gin.Context) { // POST /flat/{id}/resubmit
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of flat")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	var req models.FlatUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request data", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.Price != nil && *req.Price < 0 {
+		abort(c, ctx, h.logger, slog.LevelInfo, "price value is inappropriate", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Room < 0 {
+		abort(c, ctx, h.logger, slog.LevelInfo, "room value is inappropriate", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Status != nil || req.DeclineReason != nil || req.Comment != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "only attributes of flat can be changed on resubmission", nil, http.StatusBadRequest)
+		return
+	}
+	if msg := checkFlatAttributes(req.Number, req.TotalArea, req.LivingArea); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	req.ID = id
+
+	flat, srvErr := h.houseFlatService.ResubmitFlat(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flat)
+}
+
+func (h Handler) moderationStart(c *gin.Context) { // POST /flat/{id}/moderation/start
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of flat")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	flat, srvErr := h.houseFlatService.StartModeration(ctx, id, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flat)
+}
+
+func (h Handler) moderationFinish(c *gin.Context) { // POST /flat/{id}/moderation/finish
+	ctx := parseRequestContext(c, h.logger)
+	id, err := paramInt(c, "id")
+	if err != nil {
+		paramIntErrorHandler(c, ctx, h.logger, err, "id of flat")
+		return
+	}
+	user, err := parseUser(c)
+	if err != nil {
+		parseUserErrorHandler(c, ctx, h.logger, err)
+		return
+	}
+
+	var req models.FlatUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request data", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.Price != nil && *req.Price < 0 {
+		abort(c, ctx, h.logger, slog.LevelInfo, "price value is inappropriate", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Room < 0 {
+		abort(c, ctx, h.logger, slog.LevelInfo, "room value is inappropriate", nil, http.StatusBadRequest)
+		return
+	}
+	if req.Status != nil && !moderation.IsDecision(*req.Status) {
+		abort(c, ctx, h.logger, slog.LevelInfo, "status value is inappropriate", nil, http.StatusBadRequest)
+		return
+	}
+
+	if msg := checkFlatAttributes(req.Number, req.TotalArea, req.LivingArea); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+	req.ID = id
+
+	flat, srvErr := h.houseFlatService.FinishModeration(ctx, req, user)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, srvErr.Cause().Error(), nil, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flat)
+}
+
+func (h Handler) moderationQueue(c *gin.Context) { // GET /moderation/queue
+	ctx := parseRequestContext(c, h.logger)
+	var req models.ModerationQueueRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot parse request parameters", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if msg := checkPage(req.PageRequest, []string{models.FlatSortCreatedAt}); msg != "" {
+		abort(c, ctx, h.logger, slog.LevelInfo, msg, nil, http.StatusBadRequest)
+		return
+	}
+
+	flats, err := h.houseFlatService.ModerationQueue(ctx, req)
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, err.Cause().Error(), nil, codeByStatus(err.Status()), err.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, flats)
+}
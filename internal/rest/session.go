@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h Handler) logout(c *gin.Context) { // POST /logout
+	ctx := parseRequestContext(c, h.logger)
+
+	token, err := parseAccessToken(c)
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke token", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.Revoke(ctx, token); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke token", map[string]any{"error": err.Cause()}, codeByStatus(err.Status()), err.Code())
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h Handler) logoutAll(c *gin.Context) { // POST /logout/all
+	ctx := parseRequestContext(c, h.logger)
+
+	token, err := parseAccessToken(c)
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke tokens", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.RevokeUser(ctx, token.UserID); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke tokens of user", map[string]any{"error": err.Cause()}, codeByStatus(err.Status()), err.Code())
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h Handler) userLogout(c *gin.Context) { // POST /user/:id/logout
+	ctx := parseRequestContext(c, h.logger)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "id of user is not a uuid", nil, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.RevokeUser(ctx, id); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke tokens of user", map[string]any{"error": err.Cause(), "user_id": id}, codeByStatus(err.Status()), err.Code())
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h Handler) mySessions(c *gin.Context) { // GET /me/sessions
+	ctx := parseRequestContext(c, h.logger)
+
+	token, err := parseAccessToken(c)
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot get sessions", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	resp, srvErr := h.tokenService.Sessions(ctx, token)
+	if srvErr != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot get sessions", map[string]any{"error": srvErr.Cause()}, codeByStatus(srvErr.Status()), srvErr.Code())
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h Handler) mySessionDelete(c *gin.Context) { // DELETE /me/sessions/:id
+	ctx := parseRequestContext(c, h.logger)
+
+	token, err := parseAccessToken(c)
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke session", map[string]any{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "id of session is not a uuid", nil, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenService.RevokeSession(ctx, token.UserID, id); err != nil {
+		abort(c, ctx, h.logger, slog.LevelInfo, "cannot revoke session", map[string]any{"error": err.Cause(), "session_id": id}, codeByStatus(err.Status()), err.Code())
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// clientInfo describes the device the request is sent from
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+)
+
+type DeveloperServicer interface {
+	CreateDeveloper(context.Context, models.DeveloperCreateRequest) (models.Developer, Error)
+	Developer(context.Context, int) (models.Developer, Error)
+	Developers(context.Context) (models.DeveloperListResponse, Error)
+	UpdateDeveloper(context.Context, models.DeveloperUpdateRequest) (models.Developer, Error)
+	DeleteDeveloper(context.Context, int) Error
+	DeveloperHouses(context.Context, int, models.HouseListRequest, models.User) (models.HouseListResponse, Error)
+}
+
+type DeveloperService struct {
+	developerStorage repository.DeveloperStorage
+	houseService     HouseFlatServicer
+}
+
+var _ DeveloperServicer = DeveloperService{}
+
+func NewDeveloperService(ds repository.DeveloperStorage, hs HouseFlatServicer) DeveloperService {
+	return DeveloperService{
+		developerStorage: ds,
+		houseService:     hs,
+	}
+}
+
+func (d DeveloperService) CreateDeveloper(ctx context.Context, req models.DeveloperCreateRequest) (models.Developer, Error) {
+	developer, err := d.developerStorage.Create(ctx, req)
+	if err != nil {
+		return models.Developer{}, developerError(err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperService) Developer(ctx context.Context, id int) (models.Developer, Error) {
+	developer, err := d.developerStorage.Get(ctx, id)
+	if err != nil {
+		return models.Developer{}, developerError(err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperService) Developers(ctx context.Context) (models.DeveloperListResponse, Error) {
+	developers, err := d.developerStorage.List(ctx)
+	if err != nil {
+		return models.DeveloperListResponse{}, developerError(err)
+	}
+	if developers == nil {
+		developers = []models.Developer{}
+	}
+
+	return models.DeveloperListResponse{
+		Developers: developers,
+	}, nil
+}
+
+func (d DeveloperService) UpdateDeveloper(ctx context.Context, req models.DeveloperUpdateRequest) (models.Developer, Error) {
+	developer, err := d.developerStorage.Update(ctx, req)
+	if err != nil {
+		return models.Developer{}, developerError(err)
+	}
+
+	return developer, nil
+}
+
+func (d DeveloperService) DeleteDeveloper(ctx context.Context, id int) Error {
+	if err := d.developerStorage.Delete(ctx, id); err != nil {
+		return developerError(err)
+	}
+
+	return nil
+}
+
+// DeveloperHouses returns houses of the developer with the same filters and pagination as the list of houses
+func (d DeveloperService) DeveloperHouses(ctx context.Context, id int, req models.HouseListRequest, user models.User) (models.HouseListResponse, Error) {
+	if _, err := d.Developer(ctx, id); err != nil {
+		return models.HouseListResponse{}, err
+	}
+	req.DeveloperID = &id
+
+	return d.houseService.Houses(ctx, req, user)
+}
@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository/mock"
+	"github.com/antsrp/house_service/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevelopers(t *testing.T) {
+	base := mock.NewBase()
+	houses := service.NewHouseFlatService(mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), mock.NewDeveloperStorage(&base),
+		service.NewMockSubscriberService())
+	s := service.NewDeveloperService(mock.NewDeveloperStorage(&base), houses)
+	ctx := context.Background()
+	client := models.User{UserType: models.Client}
+
+	year := 2010
+	var ids []int
+	for i, name := range []string{"PEEK", "Peek", " peek "} {
+		house, err := houses.CreateHouse(ctx, models.HouseCreateRequest{Address: fmt.Sprintf("addr %d", i), Year: &year, Developer: &name})
+		require.Nil(t, err)
+		require.NotNil(t, house.DeveloperID)
+		require.Equal(t, "PEEK", house.Developer)
+		ids = append(ids, *house.DeveloperID)
+	}
+	require.Equal(t, ids[0], ids[1])
+	require.Equal(t, ids[0], ids[2])
+
+	_, err := s.CreateDeveloper(ctx, models.DeveloperCreateRequest{Name: "peek"})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	name := "ПИК"
+	developer, err := s.UpdateDeveloper(ctx, models.DeveloperUpdateRequest{ID: ids[0], Name: &name})
+	require.Nil(t, err)
+	require.Equal(t, name, developer.Name)
+
+	resp, err := s.DeveloperHouses(ctx, developer.ID, models.HouseListRequest{}, client)
+	require.Nil(t, err)
+	require.Len(t, resp.Houses, 3)
+	for _, house := range resp.Houses {
+		require.Equal(t, name, house.Developer)
+	}
+
+	err = s.DeleteDeveloper(ctx, developer.ID)
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	other, err := s.CreateDeveloper(ctx, models.DeveloperCreateRequest{Name: "LSR"})
+	require.Nil(t, err)
+	list, err := s.Developers(ctx)
+	require.Nil(t, err)
+	require.Len(t, list.Developers, 2)
+	require.Nil(t, s.DeleteDeveloper(ctx, other.ID))
+
+	_, err = s.DeveloperHouses(ctx, other.ID, models.HouseListRequest{}, client)
+	require.NotNil(t, err)
+
+	missing := 100
+	_, err = houses.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year, DeveloperID: &missing})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/antsrp/house_service/internal/domain/moderation"
 	"github.com/antsrp/house_service/internal/repository"
 )
 
@@ -11,6 +13,7 @@ type ErrorStatus = string
 const (
 	BadRequest ErrorStatus = "bad request"
 	Conflict   ErrorStatus = "conflict"
+	Forbidden  ErrorStatus = "forbidden"
 	Internal   ErrorStatus = "internal error"
 )
 
@@ -20,6 +23,12 @@ const (
 	DatabaseErrorCode ErrorCode = iota + 1
 	CreateTokenErrorCode
 	CryptoErrorCode
+	PaginationErrorCode
+	PermissionErrorCode
+	TransitionErrorCode
+	ModerationErrorCode
+	ValidationErrorCode
+	ParseTokenErrorCode
 )
 
 type Error interface {
@@ -65,10 +74,71 @@ func NewServiceError(status ErrorStatus, err error, code ErrorCode) Error {
 }
 
 var (
-	ErrDefaultInternalError = fmt.Errorf("internal server error, try again later")
-	ErrHouseNotFound        = fmt.Errorf("house not found")
-	ErrFlatNotFound         = fmt.Errorf("flat not found")
-	ErrOnModeration         = fmt.Errorf("flat is already on moderation")
-	ErrUserAlreadyExists    = fmt.Errorf("user already exists")
-	ErrUserNotFound         = fmt.Errorf("user not found")
+	ErrDefaultInternalError    = fmt.Errorf("internal server error, try again later")
+	ErrHouseNotFound           = fmt.Errorf("house not found")
+	ErrFlatNotFound            = fmt.Errorf("flat not found")
+	ErrOnModeration            = fmt.Errorf("flat is already on moderation")
+	ErrUserAlreadyExists       = fmt.Errorf("user already exists")
+	ErrUserNotFound            = fmt.Errorf("user not found")
+	ErrInvalidCursor           = fmt.Errorf("invalid cursor")
+	ErrHouseArchived           = fmt.Errorf("house is archived")
+	ErrInvalidFlatStatus       = fmt.Errorf("invalid flat status")
+	ErrNotFlatCreator          = fmt.Errorf("flat is created by another user")
+	ErrUnexpectedDeclineReason = fmt.Errorf("decline reason can be set only for declined flat")
+	ErrUnregisteredUser        = fmt.Errorf("user is not registered")
+	ErrFlatNumberTaken         = fmt.Errorf("flat with the same number already exists in the house")
+	ErrFloorOutOfRange         = fmt.Errorf("floor is above the top floor of the house")
+	ErrDeveloperNotFound       = fmt.Errorf("developer not found")
+	ErrDeveloperAlreadyExists  = fmt.Errorf("developer with the same name already exists")
+	ErrDeveloperHasHouses      = fmt.Errorf("developer has houses")
+	ErrNothingToUpdate         = fmt.Errorf("nothing to update")
+	ErrEmptySearchQuery        = fmt.Errorf("search query has no words")
+	ErrInvalidReportPeriod     = fmt.Errorf("report period is invalid or too long")
+	ErrFlatNotCreated          = fmt.Errorf("flat can't be created")
+	ErrInvalidToken            = fmt.Errorf("invalid token")
+	ErrTokenRevoked            = fmt.Errorf("token is revoked")
+	ErrTokenExpired            = fmt.Errorf("token is expired")
+	ErrSessionNotFound         = fmt.Errorf("session not found")
+	ErrTokenReused             = fmt.Errorf("refresh token is already used, all tokens of the session are revoked")
 )
+
+// DuplicateHouseError is returned when not archived house with the same normalized address already exists
+type DuplicateHouseError struct {
+	ID int // id of existing house
+}
+
+func (e *DuplicateHouseError) Error() string {
+	return fmt.Sprintf("house with the same address already exists, id: %d", e.ID)
+}
+
+// flatError converts storage error of flat operation to service error, recognizing illegal status transitions
+func flatError(err repository.DatabaseError) Error {
+	var transitionErr *moderation.TransitionError
+	switch {
+	case errors.As(err.Cause(), &transitionErr):
+		return NewServiceError(Conflict, transitionErr, TransitionErrorCode)
+	case errors.Is(err.Cause(), moderation.ErrClaimedByAnother), errors.Is(err.Cause(), moderation.ErrNotClaimed),
+		errors.Is(err.Cause(), moderation.ErrClaimExpired):
+		return NewServiceError(Conflict, err.Cause(), ModerationErrorCode)
+	case errors.Is(err.Cause(), moderation.ErrUnknownStatus):
+		return NewServiceError(BadRequest, ErrInvalidFlatStatus, TransitionErrorCode)
+	case errors.Is(err.Cause(), repository.ErrEntityNotFound):
+		return NewServiceError(StatusByError(err), ErrFlatNotFound, DatabaseErrorCode)
+	case errors.Is(err.Cause(), repository.ErrEntityAlreadyExists):
+		return NewServiceError(Conflict, ErrFlatNumberTaken, DatabaseErrorCode)
+	}
+	return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+}
+
+// developerError converts storage error of developer operation to service error
+func developerError(err repository.DatabaseError) Error {
+	switch {
+	case errors.Is(err.Cause(), repository.ErrEntityNotFound):
+		return NewServiceError(StatusByError(err), ErrDeveloperNotFound, DatabaseErrorCode)
+	case errors.Is(err.Cause(), repository.ErrEntityAlreadyExists):
+		return NewServiceError(Conflict, ErrDeveloperAlreadyExists, DatabaseErrorCode)
+	case errors.Is(err.Cause(), repository.ErrEntityInUse):
+		return NewServiceError(Conflict, ErrDeveloperHasHouses, DatabaseErrorCode)
+	}
+	return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+}
@@ -0,0 +1,497 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository/mock"
+	"github.com/antsrp/house_service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatsList(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year := 2020
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	moderator := models.User{ID: uuid.New(), UserType: models.Moderator}
+	for i := 0; i < 6; i++ {
+		price := 1000 * (i + 1)
+		flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: i%2 + 1})
+		require.Nil(t, err)
+		if i < 4 {
+			_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID}, moderator)
+			require.Nil(t, err)
+		}
+	}
+
+	room, priceTo := 2, 5000
+	req := models.FlatListRequest{Room: &room, PriceTo: &priceTo}
+	resp, err := s.FlatsList(ctx, req, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, resp.Flats, 2)
+	for _, flat := range resp.Flats {
+		require.Equal(t, models.Approved, flat.Status)
+	}
+
+	resp, err = s.FlatsList(ctx, req, moderator)
+	require.Nil(t, err)
+	require.Len(t, resp.Flats, 2)
+
+	status := models.Created
+	req = models.FlatListRequest{Status: &status}
+	req.SortBy, req.Order, req.Limit = models.FlatSortPrice, models.Desc, 1
+	resp, err = s.FlatsList(ctx, req, moderator)
+	require.Nil(t, err)
+	require.Len(t, resp.Flats, 1)
+	require.Equal(t, 6000, resp.Flats[0].Price)
+
+	req.Cursor = resp.NextCursor
+	resp, err = s.FlatsList(ctx, req, moderator)
+	require.Nil(t, err)
+	require.Len(t, resp.Flats, 1)
+	require.Equal(t, 5000, resp.Flats[0].Price)
+	require.Empty(t, resp.NextCursor)
+}
+
+func TestWithdrawFlat(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 100
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	owner := models.User{ID: uuid.New(), UserType: models.Client}
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1, CreatedBy: owner.ID})
+	require.Nil(t, err)
+
+	_, err = s.WithdrawFlat(ctx, flat.ID, models.User{ID: uuid.New(), UserType: models.Client})
+	require.NotNil(t, err)
+	require.Equal(t, service.Forbidden, err.Status())
+	require.Equal(t, service.PermissionErrorCode, err.Code())
+
+	withdrawn, err := s.WithdrawFlat(ctx, flat.ID, owner)
+	require.Nil(t, err)
+	require.Equal(t, models.Withdrawn, withdrawn.Status)
+
+	_, err = s.WithdrawFlat(ctx, flat.ID, models.User{UserType: models.Moderator})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+}
+
+func TestUpdateFlatTransitions(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 100
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	moderator := models.User{ID: uuid.New(), UserType: models.Moderator}
+	for _, status := range []models.FlatStatus{models.Created, models.OnModeration, "garbage"} {
+		_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &status}, moderator)
+		require.NotNilf(t, err, "status %s should not be accepted", status)
+		require.Equal(t, service.BadRequest, err.Status())
+	}
+
+	declined := models.Declined
+	updated, err := s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined}, moderator)
+	require.Nil(t, err)
+	require.Equal(t, models.Declined, updated.Status)
+
+	_, err = s.WithdrawFlat(ctx, flat.ID, moderator)
+	require.Nil(t, err)
+
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID}, moderator)
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+	require.Equal(t, service.TransitionErrorCode, err.Code())
+}
+
+func TestTwoPhaseModeration(t *testing.T) {
+	base := mock.NewBase()
+	s := service.NewHouseFlatService(mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), mock.NewDeveloperStorage(&base), service.NewMockSubscriberService(),
+		service.WithClaimTimeout(50*time.Millisecond))
+	ctx := context.Background()
+	year, price := 2020, 100
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	first, second := models.User{ID: uuid.New(), UserType: models.Moderator}, models.User{ID: uuid.New(), UserType: models.Moderator}
+	claimed, err := s.StartModeration(ctx, flat.ID, first)
+	require.Nil(t, err)
+	require.Equal(t, models.OnModeration, claimed.Status)
+
+	_, err = s.StartModeration(ctx, flat.ID, second)
+	require.NotNil(t, err)
+	require.Equal(t, service.ModerationErrorCode, err.Code())
+	_, err = s.FinishModeration(ctx, models.FlatUpdateRequest{ID: flat.ID}, second)
+	require.NotNil(t, err)
+	require.Equal(t, service.ModerationErrorCode, err.Code())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = s.FinishModeration(ctx, models.FlatUpdateRequest{ID: flat.ID}, first)
+	require.NotNil(t, err)
+
+	cnt, err := s.ReleaseExpiredModeration(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+
+	_, err = s.StartModeration(ctx, flat.ID, second)
+	require.Nil(t, err)
+	declined := models.Declined
+	finished, err := s.FinishModeration(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined}, second)
+	require.Nil(t, err)
+	require.Equal(t, models.Declined, finished.Status)
+}
+
+func TestExpiredModerationRestoresStatus(t *testing.T) {
+	base := mock.NewBase()
+	s := service.NewHouseFlatService(mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), mock.NewDeveloperStorage(&base), service.NewMockSubscriberService(),
+		service.WithClaimTimeout(50*time.Millisecond))
+	ctx := context.Background()
+	year, price := 2020, 100
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	moderator := models.User{ID: uuid.New(), UserType: models.Moderator}
+	approved := models.Approved
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &approved}, moderator)
+	require.Nil(t, err)
+
+	_, err = s.StartModeration(ctx, flat.ID, moderator)
+	require.Nil(t, err)
+	time.Sleep(60 * time.Millisecond)
+	cnt, err := s.ReleaseExpiredModeration(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+
+	flats, err := s.Flats(ctx, models.HouseGetFlatsRequest{ID: house.ID}, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, flats.Flats, 1)
+	require.Equal(t, models.Approved, flats.Flats[0].Status, "flat should be approved again after expired moderation")
+}
+
+func TestDummyModeratorCantClaim(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 100
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	dummy := models.User{UserType: models.Moderator}
+	_, err = s.StartModeration(ctx, flat.ID, dummy)
+	require.NotNil(t, err)
+	require.Equal(t, service.Forbidden, err.Status())
+	require.Equal(t, service.PermissionErrorCode, err.Code())
+	_, err = s.FinishModeration(ctx, models.FlatUpdateRequest{ID: flat.ID}, dummy)
+	require.NotNil(t, err)
+	require.Equal(t, service.Forbidden, err.Status())
+
+	// single step moderation doesn't leave a claim, so it's available for dummy moderators
+	approved := models.Approved
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &approved}, dummy)
+	require.Nil(t, err)
+}
+
+func TestModerationQueue(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 100
+	first, second := "PEEK", "LSR"
+	firstHouse, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr1", Year: &year, Developer: &first})
+	require.Nil(t, err)
+	secondHouse, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr2", Year: &year, Developer: &second})
+	require.Nil(t, err)
+
+	var ids []int
+	for i := 0; i < 4; i++ {
+		houseID := firstHouse.ID
+		if i%2 == 1 {
+			houseID = secondHouse.ID
+		}
+		flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: houseID, Price: &price, Room: 1})
+		require.Nil(t, err)
+		ids = append(ids, flat.ID)
+	}
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: ids[0]}, models.User{UserType: models.Moderator})
+	require.Nil(t, err)
+
+	req := models.ModerationQueueRequest{}
+	req.Limit, req.Order = 2, models.Desc // order is ignored, oldest flats go first
+	queue, err := s.ModerationQueue(ctx, req)
+	require.Nil(t, err)
+	require.Equal(t, []int{ids[1], ids[2]}, []int{queue.Flats[0].ID, queue.Flats[1].ID})
+
+	req.Cursor = queue.NextCursor
+	queue, err = s.ModerationQueue(ctx, req)
+	require.Nil(t, err)
+	require.Len(t, queue.Flats, 1)
+	require.Equal(t, ids[3], queue.Flats[0].ID)
+
+	developer := "peek"
+	queue, err = s.ModerationQueue(ctx, models.ModerationQueueRequest{Developer: &developer})
+	require.Nil(t, err)
+	require.Len(t, queue.Flats, 1)
+	require.Equal(t, ids[2], queue.Flats[0].ID)
+}
+
+func TestFlatDeclineReason(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 1000
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	creator, other := models.User{ID: uuid.New(), UserType: models.Client}, models.User{ID: uuid.New(), UserType: models.Client}
+	moderator := models.User{ID: uuid.New(), UserType: models.Moderator}
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1, CreatedBy: creator.ID})
+	require.Nil(t, err)
+
+	approved, reason, comment := models.Approved, "no photos", "add photos of the kitchen"
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &approved, DeclineReason: &reason}, moderator)
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+
+	declined := models.Declined
+	resp, err := s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined, DeclineReason: &reason, Comment: &comment}, moderator)
+	require.Nil(t, err)
+	require.NotNil(t, resp.Moderation)
+	require.Equal(t, reason, resp.Moderation.DeclineReason)
+
+	for _, user := range []models.User{creator, moderator} {
+		got, err := s.GetFlat(ctx, flat.ID, user)
+		require.Nil(t, err)
+		require.NotNil(t, got.Moderation)
+		require.Equal(t, models.Declined, got.Moderation.Status)
+		require.Equal(t, reason, got.Moderation.DeclineReason)
+		require.Equal(t, comment, got.Moderation.Comment)
+	}
+
+	_, err = s.GetFlat(ctx, flat.ID, other)
+	require.NotNil(t, err)
+}
+
+func TestFlatResubmit(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 1000
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	creator, other := models.User{ID: uuid.New(), UserType: models.Client}, models.User{ID: uuid.New(), UserType: models.Client}
+	moderator := models.User{ID: uuid.New(), UserType: models.Moderator}
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1, CreatedBy: creator.ID})
+	require.Nil(t, err)
+
+	newPrice := 900
+	_, err = s.ResubmitFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Price: &newPrice}, creator)
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	declined := models.Declined
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined}, moderator)
+	require.Nil(t, err)
+
+	_, err = s.ResubmitFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Price: &newPrice}, other)
+	require.NotNil(t, err)
+	require.Equal(t, service.Forbidden, err.Status())
+	require.Equal(t, service.PermissionErrorCode, err.Code())
+
+	resubmitted, err := s.ResubmitFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Price: &newPrice, Room: 2}, creator)
+	require.Nil(t, err)
+	require.Equal(t, models.Created, resubmitted.Status)
+	require.Equal(t, newPrice, resubmitted.Price)
+	require.Equal(t, 2, resubmitted.Room)
+
+	queue, err := s.ModerationQueue(ctx, models.ModerationQueueRequest{})
+	require.Nil(t, err)
+	require.Len(t, queue.Flats, 1)
+	require.Equal(t, flat.ID, queue.Flats[0].ID)
+}
+
+func TestUserFlats(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 1000
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	creator, other := models.User{ID: uuid.New(), UserType: models.Client}, models.User{ID: uuid.New(), UserType: models.Client}
+	moderator := models.User{ID: uuid.New(), UserType: models.Moderator}
+	var ids []int
+	for _, user := range []models.User{creator, other, creator} {
+		flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1, CreatedBy: user.ID})
+		require.Nil(t, err)
+		ids = append(ids, flat.ID)
+	}
+	declined := models.Declined
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: ids[0], Status: &declined}, moderator)
+	require.Nil(t, err)
+
+	resp, err := s.UserFlats(ctx, models.FlatListRequest{}, creator)
+	require.Nil(t, err)
+	require.Len(t, resp.Flats, 2)
+	require.Equal(t, ids[0], resp.Flats[0].ID)
+	require.Equal(t, models.Declined, resp.Flats[0].Status)
+	require.NotNil(t, resp.Flats[0].Moderation)
+	require.Equal(t, ids[2], resp.Flats[1].ID)
+
+	resp, err = s.UserFlats(ctx, models.FlatListRequest{Status: &declined}, creator)
+	require.Nil(t, err)
+	require.Len(t, resp.Flats, 1)
+
+	_, err = s.UserFlats(ctx, models.FlatListRequest{}, models.User{UserType: models.Client})
+	require.NotNil(t, err)
+	require.Equal(t, service.Forbidden, err.Status())
+	require.Equal(t, service.PermissionErrorCode, err.Code())
+}
+
+func TestFlatNumber(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price, floor, totalArea, livingArea := 2020, 1000, 3, 54.5, 32.1
+	first, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "first", Year: &year})
+	require.Nil(t, err)
+	second, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "second", Year: &year})
+	require.Nil(t, err)
+
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: first.ID, Number: 12, Floor: &floor,
+		TotalArea: &totalArea, LivingArea: &livingArea, Price: &price, Room: 2})
+	require.Nil(t, err)
+	require.Equal(t, 12, flat.Number)
+	require.Equal(t, &floor, flat.Floor)
+	require.Equal(t, totalArea, flat.TotalArea)
+
+	_, err = s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: first.ID, Number: 12, Price: &price, Room: 1})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	_, err = s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: second.ID, Number: 12, Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	other, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: first.ID, Number: 13, Price: &price, Room: 1})
+	require.Nil(t, err)
+	number := 12
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: other.ID, Number: &number}, models.User{UserType: models.Moderator})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	// failed update doesn't leave the flat claimed
+	claimed, err := s.StartModeration(ctx, other.ID, models.User{ID: uuid.New(), UserType: models.Moderator})
+	require.Nil(t, err, "flat should not stay on moderation after failed update")
+	require.Equal(t, 13, claimed.Number)
+}
+
+func TestFlatFloor(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, floors, price := 2020, 5, 1000
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "first", Year: &year, Floors: &floors})
+	require.Nil(t, err)
+
+	// number is optional, ground and basement floors are allowed
+	for _, floor := range []int{0, -1, 5} {
+		flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Floor: &floor, Price: &price, Room: 1})
+		require.Nil(t, err)
+		require.Equal(t, 0, flat.Number)
+		require.Equal(t, &floor, flat.Floor)
+	}
+
+	floor := 6
+	_, err = s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Floor: &floor, Price: &price, Room: 1})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+	require.ErrorIs(t, err.Cause(), service.ErrFloorOutOfRange)
+
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.Nil(t, err)
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Floor: &floor}, models.User{ID: uuid.New(), UserType: models.Moderator})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+}
+
+func TestFlatPriceHistory(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 1000
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	creator, moderator := models.User{ID: uuid.New(), UserType: models.Client}, models.User{ID: uuid.New(), UserType: models.Moderator}
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1, CreatedBy: creator.ID})
+	require.Nil(t, err)
+
+	declined, newPrice := models.Declined, 1200
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined, Price: &newPrice}, moderator)
+	require.Nil(t, err)
+	_, err = s.ResubmitFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Room: 2}, creator) // price is not changed
+	require.Nil(t, err)
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined}, moderator)
+	require.Nil(t, err)
+	lowered := 1100
+	_, err = s.ResubmitFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Price: &lowered}, creator)
+	require.Nil(t, err)
+
+	resp, err := s.FlatPriceHistory(ctx, flat.ID)
+	require.Nil(t, err)
+	require.Len(t, resp.History, 2)
+	require.Equal(t, [2]int{1000, 1200}, [2]int{resp.History[0].OldPrice, resp.History[0].NewPrice})
+	require.Equal(t, moderator.ID, *resp.History[0].ChangedBy)
+	require.Equal(t, [2]int{1200, 1100}, [2]int{resp.History[1].OldPrice, resp.History[1].NewPrice})
+	require.Equal(t, creator.ID, *resp.History[1].ChangedBy)
+
+	_, err = s.FlatPriceHistory(ctx, flat.ID+1)
+	require.NotNil(t, err)
+}
+
+func TestImportFlats(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2020, 1000
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+	_, err = s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Number: 1, Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	rows := []models.FlatImportRow{
+		{Line: 2, Flat: models.FlatCreateRequest{HouseID: house.ID, Number: 1, Price: &price, Room: 1}}, // number is taken
+		{Line: 3, Flat: models.FlatCreateRequest{HouseID: house.ID, Number: 2, Price: &price, Room: 2}},
+		{Line: 4, Error: "room field is not set or value is inappropriate"},
+		{Line: 5, Flat: models.FlatCreateRequest{HouseID: house.ID, Number: 3, Price: &price, Room: 3}},
+		{Line: 6, Flat: models.FlatCreateRequest{HouseID: house.ID, Number: 3, Price: &price, Room: 1}}, // duplicate inside the file
+	}
+	resp, err := s.ImportFlats(ctx, house.ID, rows)
+	require.Nil(t, err)
+	require.Equal(t, 2, resp.Accepted)
+	require.Equal(t, 3, resp.Rejected)
+	require.Len(t, resp.Lines, 5)
+	require.Equal(t, service.ErrFlatNumberTaken.Error(), resp.Lines[0].Error)
+	require.NotZero(t, resp.Lines[1].FlatID)
+	require.Equal(t, rows[2].Error, resp.Lines[2].Error)
+	require.Equal(t, 5, resp.Lines[3].Line)
+	require.NotZero(t, resp.Lines[3].FlatID)
+	require.NotEmpty(t, resp.Lines[4].Error)
+
+	flats, err := s.Flats(ctx, models.HouseGetFlatsRequest{ID: house.ID}, models.User{UserType: models.Moderator})
+	require.Nil(t, err)
+	require.Len(t, flats.Flats, 3)
+
+	_, err = s.ArchiveHouse(ctx, house.ID)
+	require.Nil(t, err)
+	_, err = s.ImportFlats(ctx, house.ID, rows[1:2])
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+}
@@ -0,0 +1,273 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository/mock"
+	"github.com/antsrp/house_service/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func newHouseService() service.HouseFlatService {
+	base := mock.NewBase()
+	return service.NewHouseFlatService(mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), mock.NewDeveloperStorage(&base), service.NewMockSubscriberService())
+}
+
+func TestHousesList(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	developers := []string{"PEEK", "Samolet", "peek", "LSR", "Peek"}
+	for i, developer := range developers {
+		year, developer := 2000+i, developer
+		_, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: fmt.Sprintf("Lenina street, %d", i), Year: &year, Developer: &developer})
+		require.Nil(t, err)
+	}
+
+	developer := "peek"
+	req := models.HouseListRequest{Developer: &developer}
+	req.SortBy, req.Order, req.Limit = models.HouseSortYear, models.Desc, 2
+
+	resp, err := s.Houses(ctx, req, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Equal(t, []int{2004, 2002}, []int{resp.Houses[0].Year, resp.Houses[1].Year})
+	require.NotEmpty(t, resp.NextCursor)
+
+	req.Cursor = resp.NextCursor
+	resp, err = s.Houses(ctx, req, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, resp.Houses, 1)
+	require.Equal(t, 2000, resp.Houses[0].Year)
+	require.Empty(t, resp.NextCursor)
+}
+
+func TestHousesListInvalidCursor(t *testing.T) {
+	s := newHouseService()
+	req := models.HouseListRequest{}
+	req.Cursor = "not a cursor"
+
+	_, err := s.Houses(context.Background(), req, models.User{UserType: models.Client})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+}
+
+func TestHouseArchive(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2010, 100
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.Nil(t, err)
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID}, models.User{UserType: models.Moderator})
+	require.Nil(t, err)
+
+	address := "new addr"
+	updated, err := s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: house.ID, Address: &address})
+	require.Nil(t, err)
+	require.Equal(t, address, updated.Address)
+	require.Equal(t, year, updated.Year)
+
+	_, err = s.ArchiveHouse(ctx, house.ID)
+	require.Nil(t, err)
+	_, err = s.ArchiveHouse(ctx, house.ID)
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	client, moderator := models.User{UserType: models.Client}, models.User{UserType: models.Moderator}
+	_, err = s.Flats(ctx, models.HouseGetFlatsRequest{ID: house.ID}, client)
+	require.NotNil(t, err)
+	_, err = s.Flats(ctx, models.HouseGetFlatsRequest{ID: house.ID}, moderator)
+	require.Nil(t, err)
+
+	houses, err := s.Houses(ctx, models.HouseListRequest{}, client)
+	require.Nil(t, err)
+	require.Empty(t, houses.Houses)
+	flats, err := s.FlatsList(ctx, models.FlatListRequest{}, client)
+	require.Nil(t, err)
+	require.Empty(t, flats.Flats)
+
+	_, err = s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Price: &price, Room: 1})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+}
+
+func TestHouseAttributes(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, floors, buildingType, lat, lon := 2015, 17, models.Panel, 59.93, 30.31
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year, Floors: &floors, BuildingType: &buildingType,
+		Latitude: &lat, Longitude: &lon, Amenities: []models.Amenity{models.Parking, models.Elevator, models.Parking}})
+	require.Nil(t, err)
+	require.Equal(t, floors, house.Floors)
+	require.Equal(t, models.Panel, house.BuildingType)
+	require.Equal(t, lat, *house.Latitude)
+	require.Equal(t, []models.Amenity{models.Elevator, models.Parking}, house.Amenities)
+
+	floors = 20
+	updated, err := s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: house.ID, Floors: &floors})
+	require.Nil(t, err)
+	require.Equal(t, 20, updated.Floors)
+	require.Equal(t, []models.Amenity{models.Elevator, models.Parking}, updated.Amenities)
+
+	updated, err = s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: house.ID, Amenities: []models.Amenity{}})
+	require.Nil(t, err)
+	require.Empty(t, updated.Amenities)
+	require.Equal(t, models.Panel, updated.BuildingType)
+
+	_, err = s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: house.ID})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+	require.ErrorIs(t, err.Cause(), service.ErrNothingToUpdate)
+
+	name := "PIK"
+	other, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "other", Year: &year, Developer: &name})
+	require.Nil(t, err)
+	updated, err = s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: house.ID, DeveloperID: other.DeveloperID})
+	require.Nil(t, err)
+	require.Equal(t, other.DeveloperID, updated.DeveloperID)
+	require.Equal(t, name, updated.Developer)
+}
+
+func TestHousesNearby(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year, price := 2000, 100
+	moderator := models.User{UserType: models.Moderator}
+	points := [][2]float64{{59.9343, 30.3351}, {59.9386, 30.3141}, {59.9500, 30.3160}, {55.7558, 37.6173}}
+	var ids []int
+	for i, p := range points {
+		lat, lon := p[0], p[1]
+		house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: fmt.Sprintf("addr %d", i), Year: &year, Latitude: &lat, Longitude: &lon})
+		require.Nil(t, err)
+		ids = append(ids, house.ID)
+	}
+	flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: ids[1], Price: &price, Room: 1})
+	require.Nil(t, err)
+	_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID}, moderator)
+	require.Nil(t, err)
+	_, err = s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: ids[1], Price: &price, Room: 1})
+	require.Nil(t, err)
+
+	lat, lon, radius := 59.9343, 30.3351, 2000.0
+	resp, err := s.HousesNearby(ctx, models.HouseNearbyRequest{Latitude: &lat, Longitude: &lon, Radius: &radius}, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, resp.Houses, 2)
+	require.Equal(t, ids[0], resp.Houses[0].ID)
+	require.Equal(t, ids[1], resp.Houses[1].ID)
+	require.Less(t, *resp.Houses[0].Distance, *resp.Houses[1].Distance)
+	require.Equal(t, 1, resp.Houses[1].ApprovedFlats)
+
+	minLat, minLon, maxLat, maxLon := 59.9, 30.3, 60.0, 30.32
+	within, err := s.HousesWithin(ctx, models.HouseWithinRequest{MinLatitude: &minLat, MinLongitude: &minLon, MaxLatitude: &maxLat, MaxLongitude: &maxLon},
+		models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, within.Houses, 2)
+	require.Equal(t, []int{ids[1], ids[2]}, []int{within.Houses[0].ID, within.Houses[1].ID})
+	require.Nil(t, within.Houses[0].Distance)
+}
+
+func TestHouseDuplicateAddress(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year := 2010
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "г. Москва, улица Ленина, дом 5", Year: &year})
+	require.Nil(t, err)
+
+	_, err = s.CreateHouse(ctx, models.HouseCreateRequest{Address: "  Г Москва,  УЛ. ЛЕНИНА д.5 ", Year: &year})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+	var duplicate *service.DuplicateHouseError
+	require.ErrorAs(t, err.Cause(), &duplicate)
+	require.Equal(t, house.ID, duplicate.ID)
+
+	other, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "г. Москва, ул. Ленина, д. 7", Year: &year})
+	require.Nil(t, err)
+	address := "г. Москва, ул. Ленина, д. 7"
+	_, err = s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: other.ID, Address: &address})
+	require.Nil(t, err)
+	address = "город Москва, ул Ленина, 5"
+	_, err = s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: other.ID, Address: &address})
+	require.Nil(t, err) // building type is missing, so address differs
+	address = "город Москва, ул Ленина, д 5"
+	_, err = s.UpdateHouse(ctx, models.HouseUpdateRequest{ID: other.ID, Address: &address})
+	require.NotNil(t, err)
+	require.Equal(t, service.Conflict, err.Status())
+
+	_, err = s.ArchiveHouse(ctx, house.ID)
+	require.Nil(t, err)
+	_, err = s.CreateHouse(ctx, models.HouseCreateRequest{Address: "г Москва, ул Ленина, д 5", Year: &year})
+	require.Nil(t, err)
+}
+
+func TestSearchHouses(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year := 2010
+	var ids []int
+	for _, input := range [][2]string{
+		{"ул. Ленинская, д. 1", "ПИК"},
+		{"пр-кт Ленина, д. 2", "Самолет"},
+		{"ул. Мира, д. 3", "ПИК"},
+	} {
+		house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: input[0], Year: &year, Developer: &input[1]})
+		require.Nil(t, err)
+		ids = append(ids, house.ID)
+	}
+
+	resp, err := s.SearchHouses(ctx, models.HouseSearchRequest{Query: "ленин"}, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, resp.Houses, 2)
+	require.Equal(t, ids[1], resp.Houses[0].ID) // exact match of the word is ranked higher
+	require.Equal(t, ids[0], resp.Houses[1].ID)
+
+	resp, err = s.SearchHouses(ctx, models.HouseSearchRequest{Query: "пик, мира"}, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Len(t, resp.Houses, 1)
+	require.Equal(t, ids[2], resp.Houses[0].ID)
+
+	resp, err = s.SearchHouses(ctx, models.HouseSearchRequest{Query: "пушкина"}, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Empty(t, resp.Houses)
+
+	_, err = s.SearchHouses(ctx, models.HouseSearchRequest{Query: " & "}, models.User{UserType: models.Client})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+	require.Equal(t, service.ValidationErrorCode, err.Code())
+}
+
+func TestHouseStats(t *testing.T) {
+	s := newHouseService()
+	ctx := context.Background()
+	year := 2010
+	house, err := s.CreateHouse(ctx, models.HouseCreateRequest{Address: "addr", Year: &year})
+	require.Nil(t, err)
+
+	moderator := models.User{UserType: models.Moderator}
+	for i, input := range [][2]int{{1000, 1}, {1500, 1}, {3000, 1}, {2000, 2}, {5000, 2}} {
+		price := input[0]
+		flat, err := s.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Number: i + 1, Price: &price, Room: input[1]})
+		require.Nil(t, err)
+		if i != 4 {
+			_, err = s.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID}, moderator)
+			require.Nil(t, err)
+		}
+	}
+
+	stats, err := s.HouseStats(ctx, house.ID, moderator)
+	require.Nil(t, err)
+	require.Equal(t, map[models.FlatStatus]int{models.Approved: 4, models.Created: 1}, stats.Statuses)
+	require.Len(t, stats.Prices, 2)
+	require.Equal(t, models.RoomPriceStats{Rooms: 1, Count: 3, Min: 1000, Median: 1500, Max: 3000, Average: 1833.3333333333333}, stats.Prices[0])
+	require.Equal(t, models.RoomPriceStats{Rooms: 2, Count: 2, Min: 2000, Median: 3500, Max: 5000, Average: 3500}, stats.Prices[1])
+
+	stats, err = s.HouseStats(ctx, house.ID, models.User{UserType: models.Client})
+	require.Nil(t, err)
+	require.Equal(t, map[models.FlatStatus]int{models.Approved: 4}, stats.Statuses)
+	require.Equal(t, 2000.0, stats.Prices[1].Median)
+
+	_, err = s.HouseStats(ctx, house.ID+1, moderator)
+	require.NotNil(t, err)
+}
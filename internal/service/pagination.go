@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+)
+
+func preparePage(page *models.PageRequest, defaultSort string) Error {
+	if page.Limit <= 0 {
+		page.Limit = models.DefaultPageLimit
+	} else if page.Limit > models.MaxPageLimit {
+		page.Limit = models.MaxPageLimit
+	}
+	if page.Order == "" {
+		page.Order = models.Asc
+	}
+	if page.SortBy == "" {
+		page.SortBy = defaultSort
+	}
+	if page.Cursor != "" {
+		cursor, err := models.DecodeCursor(page.Cursor)
+		if err != nil {
+			return NewServiceError(BadRequest, fmt.Errorf("%w: %w", ErrInvalidCursor, err), PaginationErrorCode)
+		}
+		page.After = &cursor
+	}
+	return nil
+}
+
+// cutPage trims extra item fetched by storage and returns cursor to the next page if it exists
+func cutPage[T any](items []T, limit int, key func(T) models.Cursor) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, key(items[limit-1]).Encode()
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository"
+)
+
+type ReportServicer interface {
+	FlatsPerDay(context.Context, models.ReportRequest) (models.DailyFlatsResponse, Error)
+	ModeratorThroughput(context.Context, models.ReportRequest) (models.ModeratorsReportResponse, Error)
+	DeveloperPrices(context.Context, models.ReportRequest) (models.DeveloperPricesResponse, Error)
+}
+
+type ReportService struct {
+	reportStorage repository.ReportStorage
+}
+
+var _ ReportServicer = ReportService{}
+
+func NewReportService(rs repository.ReportStorage) ReportService {
+	return ReportService{
+		reportStorage: rs,
+	}
+}
+
+// period returns bounds of the report, filling the missing ones with defaults
+func period(req models.ReportRequest) (time.Time, time.Time, Error) {
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.Add(-models.DefaultReportPeriod)
+	if req.From != nil {
+		from = *req.From
+	}
+	if from.After(to) || to.Sub(from) > models.MaxReportPeriod {
+		return time.Time{}, time.Time{}, NewServiceError(BadRequest, ErrInvalidReportPeriod, ValidationErrorCode)
+	}
+	return from, to, nil
+}
+
+func (r ReportService) FlatsPerDay(ctx context.Context, req models.ReportRequest) (models.DailyFlatsResponse, Error) {
+	from, to, srvErr := period(req)
+	if srvErr != nil {
+		return models.DailyFlatsResponse{}, srvErr
+	}
+
+	days, err := r.reportStorage.FlatsPerDay(ctx, from, to)
+	if err != nil {
+		return models.DailyFlatsResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	if days == nil {
+		days = []models.DailyFlats{}
+	}
+
+	return models.DailyFlatsResponse{Days: days}, nil
+}
+
+func (r ReportService) ModeratorThroughput(ctx context.Context, req models.ReportRequest) (models.ModeratorsReportResponse, Error) {
+	from, to, srvErr := period(req)
+	if srvErr != nil {
+		return models.ModeratorsReportResponse{}, srvErr
+	}
+
+	moderators, err := r.reportStorage.ModeratorThroughput(ctx, from, to)
+	if err != nil {
+		return models.ModeratorsReportResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	if moderators == nil {
+		moderators = []models.ModeratorThroughput{}
+	}
+
+	return models.ModeratorsReportResponse{Moderators: moderators}, nil
+}
+
+func (r ReportService) DeveloperPrices(ctx context.Context, req models.ReportRequest) (models.DeveloperPricesResponse, Error) {
+	from, to, srvErr := period(req)
+	if srvErr != nil {
+		return models.DeveloperPricesResponse{}, srvErr
+	}
+
+	prices, err := r.reportStorage.DeveloperPrices(ctx, from, to)
+	if err != nil {
+		return models.DeveloperPricesResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	if prices == nil {
+		prices = []models.DeveloperPrice{}
+	}
+
+	return models.DeveloperPricesResponse{Prices: prices}, nil
+}
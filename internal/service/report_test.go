@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository/mock"
+	"github.com/antsrp/house_service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReports(t *testing.T) {
+	base := mock.NewBase()
+	houses := service.NewHouseFlatService(mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), mock.NewDeveloperStorage(&base),
+		service.NewMockSubscriberService())
+	s := service.NewReportService(mock.NewReportStorage(&base))
+	ctx := context.Background()
+
+	first, second := models.User{ID: uuid.New(), UserType: models.Moderator}, models.User{ID: uuid.New(), UserType: models.Moderator}
+	declined := models.Declined
+	for i, input := range []struct {
+		developer string
+		year      int
+	}{{"PEEK", 2010}, {"PEEK", 2015}, {"LSR", 2010}} {
+		house, err := houses.CreateHouse(ctx, models.HouseCreateRequest{Address: fmt.Sprintf("addr %d", i),
+			Year: &input.year, Developer: &input.developer})
+		require.Nil(t, err)
+		for j, price := range []int{1000, 2000, 4000} {
+			flat, err := houses.CreateFlat(ctx, models.FlatCreateRequest{HouseID: house.ID, Number: j + 1, Price: &price, Room: 1})
+			require.Nil(t, err)
+			if j == 2 {
+				_, err = houses.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID, Status: &declined}, second)
+			} else {
+				_, err = houses.UpdateFlat(ctx, models.FlatUpdateRequest{ID: flat.ID}, first)
+			}
+			require.Nil(t, err)
+		}
+	}
+
+	now := time.Now()
+	from, to := now.Add(-48*time.Hour), now.Add(time.Minute)
+	days, err := s.FlatsPerDay(ctx, models.ReportRequest{From: &from, To: &to})
+	require.Nil(t, err)
+	require.Len(t, days.Days, 3)
+	require.Equal(t, models.DailyFlats{Day: now.UTC().Format(models.ReportDayLayout), Created: 9, Approved: 6, Declined: 3}, days.Days[2])
+	require.Zero(t, days.Days[0].Created)
+
+	moderators, err := s.ModeratorThroughput(ctx, models.ReportRequest{})
+	require.Nil(t, err)
+	require.Len(t, moderators.Moderators, 2)
+	require.Equal(t, first.ID, *moderators.Moderators[0].ModeratorID)
+	require.Equal(t, models.ModeratorThroughput{ModeratorID: &second.ID, Declined: 3, Total: 3}, moderators.Moderators[1])
+
+	prices, err := s.DeveloperPrices(ctx, models.ReportRequest{})
+	require.Nil(t, err)
+	require.Len(t, prices.Prices, 3)
+	require.Equal(t, "LSR", prices.Prices[0].Developer)
+	require.Equal(t, []int{2010, 2015}, []int{prices.Prices[1].Year, prices.Prices[2].Year})
+	require.Equal(t, 1500.0, prices.Prices[1].MedianPrice)
+	require.Equal(t, 2, prices.Prices[1].Flats)
+
+	from = now.Add(-400 * 24 * time.Hour)
+	_, err = s.FlatsPerDay(ctx, models.ReportRequest{From: &from})
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+	require.Equal(t, service.ValidationErrorCode, err.Code())
+}
@@ -2,47 +2,121 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/antsrp/house_service/internal/domain/address"
 	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/domain/moderation"
 	"github.com/antsrp/house_service/internal/repository"
+	"github.com/google/uuid"
 )
 
 type HouseFlatServicer interface {
 	CreateHouse(context.Context, models.HouseCreateRequest) (models.HouseCreateResponse, Error)
 	CreateFlat(context.Context, models.FlatCreateRequest) (models.FlatCreateResponse, Error)
-	UpdateFlat(context.Context, models.FlatUpdateRequest) (models.FlatUpdateResponse, Error)
+	ImportFlats(context.Context, int, []models.FlatImportRow) (models.FlatImportResponse, Error)
+	UpdateFlat(context.Context, models.FlatUpdateRequest, models.User) (models.FlatUpdateResponse, Error)
+	StartModeration(context.Context, int, models.User) (models.Flat, Error)
+	FinishModeration(context.Context, models.FlatUpdateRequest, models.User) (models.FlatUpdateResponse, Error)
+	ReleaseExpiredModeration(context.Context) (int, Error)
+	ModerationQueue(context.Context, models.ModerationQueueRequest) (models.FlatListResponse, Error)
+	WithdrawFlat(context.Context, int, models.User) (models.Flat, Error)
+	GetFlat(context.Context, int, models.User) (models.Flat, Error)
+	FlatPriceHistory(context.Context, int) (models.FlatPriceHistoryResponse, Error)
+	ResubmitFlat(context.Context, models.FlatUpdateRequest, models.User) (models.Flat, Error)
 	Flats(context.Context, models.HouseGetFlatsRequest, models.User) (models.HouseGetFlatsResponse, Error)
+	Houses(context.Context, models.HouseListRequest, models.User) (models.HouseListResponse, Error)
+	UpdateHouse(context.Context, models.HouseUpdateRequest) (models.HouseUpdateResponse, Error)
+	HousesNearby(context.Context, models.HouseNearbyRequest, models.User) (models.HouseLocationsResponse, Error)
+	HousesWithin(context.Context, models.HouseWithinRequest, models.User) (models.HouseLocationsResponse, Error)
+	HouseStats(context.Context, int, models.User) (models.HouseStatsResponse, Error)
+	SearchHouses(context.Context, models.HouseSearchRequest, models.User) (models.HouseSearchResponse, Error)
+	ArchiveHouse(context.Context, int) (models.House, Error)
+	FlatsList(context.Context, models.FlatListRequest, models.User) (models.FlatListResponse, Error)
+	UserFlats(context.Context, models.FlatListRequest, models.User) (models.FlatListResponse, Error)
 	AddSubscriber(context.Context, string, int) Error
 }
 
 type HouseFlatService struct {
 	flatStorage       repository.FlatStorage
 	houseStorage      repository.HouseStorage
+	developerStorage  repository.DeveloperStorage
 	subscriberService SubscriberServicer
+	claimTimeout      time.Duration
 }
 
 var _ HouseFlatServicer = HouseFlatService{}
 
-func NewHouseFlatService(fs repository.FlatStorage, hs repository.HouseStorage, ss SubscriberServicer) HouseFlatService {
-	return HouseFlatService{
+const DefaultClaimTimeout = 15 * time.Minute
+
+type HouseFlatOption func(h *HouseFlatService)
+
+// WithClaimTimeout sets time after which moderation of flat is considered stale
+func WithClaimTimeout(timeout time.Duration) HouseFlatOption {
+	return func(h *HouseFlatService) {
+		h.claimTimeout = timeout
+	}
+}
+
+func NewHouseFlatService(fs repository.FlatStorage, hs repository.HouseStorage, ds repository.DeveloperStorage, ss SubscriberServicer, opts ...HouseFlatOption) HouseFlatService {
+	h := HouseFlatService{
 		flatStorage:       fs,
 		houseStorage:      hs,
+		developerStorage:  ds,
 		subscriberService: ss,
+		claimTimeout:      DefaultClaimTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&h)
 	}
+
+	return h
 }
 
 func (h HouseFlatService) CreateHouse(ctx context.Context, req models.HouseCreateRequest) (models.HouseCreateResponse, Error) {
+	req.Amenities = amenitySet(req.Amenities)
+	developer, srvErr := h.resolveDeveloper(ctx, req.DeveloperID, req.Developer)
+	if srvErr != nil {
+		return models.HouseCreateResponse{}, srvErr
+	}
+	if developer != nil {
+		req.DeveloperID, req.Developer = &developer.ID, &developer.Name
+	}
+	req.AddressNormalized = address.Normalize(req.Address)
+	if srvErr := h.checkDuplicateHouse(ctx, req.AddressNormalized, 0); srvErr != nil {
+		return models.HouseCreateResponse{}, srvErr
+	}
+
 	house, err := h.houseStorage.Create(ctx, req)
 	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityAlreadyExists) { // house with the same address was created concurrently
+			if srvErr := h.checkDuplicateHouse(ctx, req.AddressNormalized, 0); srvErr != nil {
+				return models.HouseCreateResponse{}, srvErr
+			}
+		}
 		return models.HouseCreateResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	}
 
 	return house, nil
 }
 func (h HouseFlatService) CreateFlat(ctx context.Context, req models.FlatCreateRequest) (models.FlatCreateResponse, Error) {
+	if err := h.checkFloor(ctx, req.HouseID, req.Floor); err != nil {
+		return models.FlatCreateResponse{}, err
+	}
+
 	flat, err := h.flatStorage.Create(ctx, req)
 	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityArchived) {
+			return models.FlatCreateResponse{}, NewServiceError(Conflict, ErrHouseArchived, DatabaseErrorCode)
+		}
+		if errors.Is(err.Cause(), repository.ErrEntityAlreadyExists) {
+			return models.FlatCreateResponse{}, NewServiceError(Conflict, ErrFlatNumberTaken, DatabaseErrorCode)
+		}
 		return models.FlatCreateResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	}
 
@@ -50,44 +124,567 @@ func (h HouseFlatService) CreateFlat(ctx context.Context, req models.FlatCreateR
 
 	return flat, nil
 }
-func (h HouseFlatService) UpdateFlat(ctx context.Context, req models.FlatUpdateRequest) (models.FlatUpdateResponse, Error) {
-	flat, err := h.flatStorage.Get(ctx, models.Flat{ID: req.ID})
+
+// ImportFlats creates valid rows in a single transaction and reports outcome of every row
+func (h HouseFlatService) ImportFlats(ctx context.Context, houseID int, rows []models.FlatImportRow) (models.FlatImportResponse, Error) {
+	var (
+		valid []int // indexes of rows passed to the storage
+		reqs  []models.FlatCreateRequest
+	)
+	top, err := h.topFloor(ctx, houseID)
 	if err != nil {
-		return models.FlatUpdateResponse{}, NewServiceError(StatusByError(err), fmt.Errorf("cannot get status of flat: %w", err.Cause()), DatabaseErrorCode)
+		return models.FlatImportResponse{}, err
+	}
+	rows = slices.Clone(rows)
+	for i, row := range rows {
+		if row.Error == "" && top != nil && row.Flat.Floor != nil && *row.Flat.Floor > *top {
+			rows[i].Error = ErrFloorOutOfRange.Error()
+		}
+		if rows[i].Error == "" {
+			valid = append(valid, i)
+			reqs = append(reqs, row.Flat)
+		}
+	}
+
+	var results []repository.FlatBatchResult
+	if len(reqs) > 0 {
+		var err repository.DatabaseError
+		results, err = h.flatStorage.CreateBatch(ctx, houseID, reqs)
+		if err != nil {
+			switch {
+			case errors.Is(err.Cause(), repository.ErrEntityArchived):
+				return models.FlatImportResponse{}, NewServiceError(Conflict, ErrHouseArchived, DatabaseErrorCode)
+			case errors.Is(err.Cause(), repository.ErrEntityNotFound):
+				return models.FlatImportResponse{}, NewServiceError(StatusByError(err), ErrHouseNotFound, DatabaseErrorCode)
+			}
+			return models.FlatImportResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+		}
+	}
+
+	resp := models.FlatImportResponse{Lines: make([]models.FlatImportLine, len(rows))}
+	for i, row := range rows {
+		resp.Lines[i] = models.FlatImportLine{Line: row.Line, Error: row.Error}
+	}
+	for j, result := range results {
+		line := &resp.Lines[valid[j]]
+		switch {
+		case result.Err == nil:
+			line.FlatID = result.Flat.ID
+		case errors.Is(result.Err, repository.ErrEntityAlreadyExists):
+			line.Error = ErrFlatNumberTaken.Error()
+		default:
+			line.Error = ErrFlatNotCreated.Error()
+		}
+	}
+	for _, line := range resp.Lines {
+		if line.Error == "" {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+	}
+
+	if resp.Accepted > 0 {
+		go h.subscriberService.SendEmail(ctx, houseID)
+	}
+
+	return resp, nil
+}
+
+func (h HouseFlatService) UpdateFlat(ctx context.Context, req models.FlatUpdateRequest, user models.User) (models.FlatUpdateResponse, Error) {
+	if err := checkDecision(&req); err != nil {
+		return models.FlatUpdateResponse{}, err
 	}
-	if flat.Status == models.OnModeration { // already moderated
-		return models.FlatUpdateResponse{}, NewServiceError(Conflict, fmt.Errorf("cannot update flat: already moderated"), DatabaseErrorCode)
+
+	if err := h.checkFlatFloor(ctx, req.ID, req.Floor); err != nil {
+		return models.FlatUpdateResponse{}, err
 	}
 
-	stat := models.OnModeration
-	if _, err := h.flatStorage.Update(ctx, models.FlatUpdateRequest{ID: req.ID, Status: &stat}); err != nil {
-		return models.FlatUpdateResponse{}, NewServiceError(StatusByError(err), fmt.Errorf("cannot update flat status to `on moderate`: %w", err.Cause()), DatabaseErrorCode)
+	res, err := h.flatStorage.Moderate(ctx, req, user.ID, h.claimTimeout)
+	if err != nil {
+		return models.FlatUpdateResponse{}, flatError(err)
 	}
 
+	return res, nil
+}
+
+// checkDecision validates status set by moderator
+func checkDecision(req *models.FlatUpdateRequest) Error {
 	if req.Status == nil { // if status is not set, change it to approved
-		stat = models.Approved
+		stat := models.Approved
 		req.Status = &stat
 	}
+	if !moderation.IsDecision(*req.Status) {
+		return NewServiceError(BadRequest, fmt.Errorf("%w: moderator can set only %v", ErrInvalidFlatStatus, moderation.Decisions), TransitionErrorCode)
+	}
+	if req.DeclineReason != nil && *req.Status != models.Declined {
+		return NewServiceError(BadRequest, ErrUnexpectedDeclineReason, TransitionErrorCode)
+	}
+	return nil
+}
+
+// StartModeration claims the flat for the moderator. Claims are bound to the moderator id,
+// so dummy moderators which share nil id can't use them
+func (h HouseFlatService) StartModeration(ctx context.Context, id int, user models.User) (models.Flat, Error) {
+	if user.ID == uuid.Nil {
+		return models.Flat{}, NewServiceError(Forbidden, ErrUnregisteredUser, PermissionErrorCode)
+	}
+	flat, err := h.flatStorage.StartModeration(ctx, id, user.ID, h.claimTimeout)
+	if err != nil {
+		return models.Flat{}, flatError(err)
+	}
 
-	res, err := h.flatStorage.Update(ctx, req)
+	return flat, nil
+}
+
+func (h HouseFlatService) FinishModeration(ctx context.Context, req models.FlatUpdateRequest, user models.User) (models.FlatUpdateResponse, Error) {
+	if user.ID == uuid.Nil {
+		return models.FlatUpdateResponse{}, NewServiceError(Forbidden, ErrUnregisteredUser, PermissionErrorCode)
+	}
+	if err := checkDecision(&req); err != nil {
+		return models.FlatUpdateResponse{}, err
+	}
+
+	if err := h.checkFlatFloor(ctx, req.ID, req.Floor); err != nil {
+		return models.FlatUpdateResponse{}, err
+	}
+
+	res, err := h.flatStorage.FinishModeration(ctx, req, user.ID, h.claimTimeout)
 	if err != nil {
-		return models.FlatUpdateResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+		return models.FlatUpdateResponse{}, flatError(err)
 	}
 
 	return res, nil
 }
+
+func (h HouseFlatService) ReleaseExpiredModeration(ctx context.Context) (int, Error) {
+	cnt, err := h.flatStorage.ReleaseExpired(ctx, h.claimTimeout)
+	if err != nil {
+		return 0, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return cnt, nil
+}
+
+func (h HouseFlatService) ModerationQueue(ctx context.Context, req models.ModerationQueueRequest) (models.FlatListResponse, Error) {
+	req.SortBy, req.Order = models.FlatSortCreatedAt, models.Asc // oldest flats first
+	if err := preparePage(&req.PageRequest, models.FlatSortCreatedAt); err != nil {
+		return models.FlatListResponse{}, err
+	}
+
+	flats, err := h.flatStorage.Queue(ctx, req)
+	if err != nil {
+		return models.FlatListResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	flats, next := cutPage(flats, req.Limit, func(flat models.Flat) models.Cursor {
+		return models.Cursor{Value: flat.SortKey(req.SortBy), ID: flat.ID}
+	})
+	if flats == nil {
+		flats = []models.Flat{}
+	}
+
+	return models.FlatListResponse{
+		Flats:      flats,
+		NextCursor: next,
+	}, nil
+}
+
+func (h HouseFlatService) WithdrawFlat(ctx context.Context, id int, user models.User) (models.Flat, Error) {
+	flat, err := h.flatStorage.Get(ctx, models.Flat{ID: id})
+	if err != nil {
+		return models.Flat{}, flatError(err)
+	}
+	if user.UserType != models.Moderator && !isCreator(flat, user) {
+		return models.Flat{}, NewServiceError(Forbidden, ErrNotFlatCreator, PermissionErrorCode)
+	}
+
+	if flat, err = h.flatStorage.Withdraw(ctx, id); err != nil {
+		return models.Flat{}, flatError(err)
+	}
+
+	return flat, nil
+}
+
+func (h HouseFlatService) ResubmitFlat(ctx context.Context, req models.FlatUpdateRequest, user models.User) (models.Flat, Error) {
+	flat, err := h.flatStorage.Get(ctx, models.Flat{ID: req.ID})
+	if err != nil {
+		return models.Flat{}, flatError(err)
+	}
+	if !isCreator(flat, user) {
+		return models.Flat{}, NewServiceError(Forbidden, ErrNotFlatCreator, PermissionErrorCode)
+	}
+
+	if err := h.checkFloor(ctx, flat.HouseID, req.Floor); err != nil {
+		return models.Flat{}, err
+	}
+
+	req.UpdatedBy = user.ID
+	if flat, err = h.flatStorage.Resubmit(ctx, req); err != nil {
+		return models.Flat{}, flatError(err)
+	}
+
+	return flat, nil
+}
+
+func (h HouseFlatService) GetFlat(ctx context.Context, id int, user models.User) (models.Flat, Error) {
+	flat, err := h.flatStorage.Get(ctx, models.Flat{ID: id})
+	if err != nil {
+		return models.Flat{}, flatError(err)
+	}
+	if user.UserType != models.Moderator && !isCreator(flat, user) {
+		if flat.Status != models.Approved {
+			return models.Flat{}, NewServiceError(BadRequest, ErrFlatNotFound, DatabaseErrorCode)
+		}
+		house, err := h.houseStorage.Get(ctx, flat.HouseID)
+		if err != nil {
+			return models.Flat{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+		}
+		if house.ArchivedAt != nil {
+			return models.Flat{}, NewServiceError(BadRequest, ErrFlatNotFound, DatabaseErrorCode)
+		}
+	}
+
+	flats := []models.Flat{flat}
+	if err := h.attachModeration(ctx, flats, user); err != nil {
+		return models.Flat{}, err
+	}
+
+	return flats[0], nil
+}
+
+func (h HouseFlatService) FlatPriceHistory(ctx context.Context, id int) (models.FlatPriceHistoryResponse, Error) {
+	if _, err := h.flatStorage.Get(ctx, models.Flat{ID: id}); err != nil {
+		return models.FlatPriceHistoryResponse{}, flatError(err)
+	}
+
+	history, err := h.flatStorage.PriceHistory(ctx, id)
+	if err != nil {
+		return models.FlatPriceHistoryResponse{}, flatError(err)
+	}
+	if history == nil {
+		history = []models.PriceChange{}
+	}
+
+	return models.FlatPriceHistoryResponse{History: history}, nil
+}
+
+func isCreator(flat models.Flat, user models.User) bool {
+	return user.ID != uuid.Nil && flat.CreatedBy == user.ID
+}
+
+// attachModeration sets result of the last moderation to the flats visible for the user: all flats for moderators, own flats for others
+func (h HouseFlatService) attachModeration(ctx context.Context, flats []models.Flat, user models.User) Error {
+	var ids []int
+	for _, flat := range flats {
+		if user.UserType == models.Moderator || isCreator(flat, user) {
+			ids = append(ids, flat.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	events, err := h.flatStorage.LastModerationEvents(ctx, ids)
+	if err != nil {
+		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	for i := range flats {
+		if event, found := events[flats[i].ID]; found {
+			flats[i].Moderation = &event
+		}
+	}
+	return nil
+}
+
 func (h HouseFlatService) Flats(ctx context.Context, req models.HouseGetFlatsRequest, user models.User) (models.HouseGetFlatsResponse, Error) {
 	flats, err := h.houseStorage.Flats(ctx, req, user)
 	if err != nil {
 		return models.HouseGetFlatsResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	}
+	if err := h.attachModeration(ctx, flats.Flats, user); err != nil {
+		return models.HouseGetFlatsResponse{}, err
+	}
 
 	return flats, nil
 }
 
+// resolveDeveloper finds developer of the house by id or by name, developer is created if there is no one with the name
+func (h HouseFlatService) resolveDeveloper(ctx context.Context, id *int, name *string) (*models.Developer, Error) {
+	if id != nil {
+		developer, err := h.developerStorage.Get(ctx, *id)
+		if err != nil {
+			return nil, developerError(err)
+		}
+		return &developer, nil
+	}
+	if name == nil || strings.TrimSpace(*name) == "" {
+		return nil, nil
+	}
+
+	developer, err := h.developerStorage.GetByName(ctx, *name)
+	if err == nil {
+		return &developer, nil
+	}
+	if !errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+		return nil, developerError(err)
+	}
+	developer, err = h.developerStorage.Create(ctx, models.DeveloperCreateRequest{Name: *name})
+	if err != nil && errors.Is(err.Cause(), repository.ErrEntityAlreadyExists) { // created by concurrent request
+		developer, err = h.developerStorage.GetByName(ctx, *name)
+	}
+	if err != nil {
+		return nil, developerError(err)
+	}
+	return &developer, nil
+}
+
+// amenitySet sorts amenities and removes duplicates
+func amenitySet(amenities []models.Amenity) []models.Amenity {
+	if amenities == nil {
+		return nil
+	}
+	set := slices.Clone(amenities)
+	slices.Sort(set)
+	return slices.Compact(set)
+}
+
+func (h HouseFlatService) UpdateHouse(ctx context.Context, req models.HouseUpdateRequest) (models.HouseUpdateResponse, Error) {
+	if req.Address == nil && req.Year == nil && req.Developer == nil && req.DeveloperID == nil && req.Floors == nil &&
+		req.BuildingType == nil && req.Latitude == nil && req.Longitude == nil && req.Amenities == nil {
+		return models.HouseUpdateResponse{}, NewServiceError(BadRequest, ErrNothingToUpdate, ValidationErrorCode)
+	}
+	req.Amenities = amenitySet(req.Amenities)
+	developer, srvErr := h.resolveDeveloper(ctx, req.DeveloperID, req.Developer)
+	if srvErr != nil {
+		return models.HouseUpdateResponse{}, srvErr
+	}
+	if developer != nil {
+		req.DeveloperID, req.Developer = &developer.ID, &developer.Name
+	}
+	if req.Address != nil {
+		normalized := address.Normalize(*req.Address)
+		if srvErr := h.checkDuplicateHouse(ctx, normalized, req.ID); srvErr != nil {
+			return models.HouseUpdateResponse{}, srvErr
+		}
+		req.AddressNormalized = &normalized
+	}
+
+	house, err := h.houseStorage.Update(ctx, req)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityAlreadyExists) && req.AddressNormalized != nil { // address was taken concurrently
+			if srvErr := h.checkDuplicateHouse(ctx, *req.AddressNormalized, req.ID); srvErr != nil {
+				return models.HouseUpdateResponse{}, srvErr
+			}
+		}
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return models.HouseUpdateResponse{}, NewServiceError(StatusByError(err), ErrHouseNotFound, DatabaseErrorCode)
+		}
+		return models.HouseUpdateResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return house, nil
+}
+
+// checkDuplicateHouse returns conflict if another not archived house has the same normalized address
+func (h HouseFlatService) checkDuplicateHouse(ctx context.Context, normalized string, id int) Error {
+	house, err := h.houseStorage.GetByAddress(ctx, normalized)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return nil
+		}
+		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	if house.ID == id {
+		return nil
+	}
+	return NewServiceError(Conflict, &DuplicateHouseError{ID: house.ID}, DatabaseErrorCode)
+}
+
+func (h HouseFlatService) HousesNearby(ctx context.Context, req models.HouseNearbyRequest, user models.User) (models.HouseLocationsResponse, Error) {
+	if req.Limit == 0 {
+		req.Limit = models.DefaultPageLimit
+	}
+
+	houses, err := h.houseStorage.Nearby(ctx, req, user)
+	if err != nil {
+		return models.HouseLocationsResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return locationsResponse(houses), nil
+}
+
+func (h HouseFlatService) HousesWithin(ctx context.Context, req models.HouseWithinRequest, user models.User) (models.HouseLocationsResponse, Error) {
+	if req.Limit == 0 {
+		req.Limit = models.DefaultPageLimit
+	}
+
+	houses, err := h.houseStorage.Within(ctx, req, user)
+	if err != nil {
+		return models.HouseLocationsResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return locationsResponse(houses), nil
+}
+
+func (h HouseFlatService) HouseStats(ctx context.Context, id int, user models.User) (models.HouseStatsResponse, Error) {
+	stats, err := h.houseStorage.Stats(ctx, id, user)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return models.HouseStatsResponse{}, NewServiceError(StatusByError(err), ErrHouseNotFound, DatabaseErrorCode)
+		}
+		return models.HouseStatsResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return stats, nil
+}
+
+func (h HouseFlatService) SearchHouses(ctx context.Context, req models.HouseSearchRequest, user models.User) (models.HouseSearchResponse, Error) {
+	if len(address.Tokens(req.Query)) == 0 {
+		return models.HouseSearchResponse{}, NewServiceError(BadRequest, ErrEmptySearchQuery, ValidationErrorCode)
+	}
+	if req.Limit == 0 {
+		req.Limit = models.DefaultPageLimit
+	}
+
+	houses, err := h.houseStorage.Search(ctx, req, user)
+	if err != nil {
+		return models.HouseSearchResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	if houses == nil {
+		houses = []models.HouseSearchResult{}
+	}
+
+	return models.HouseSearchResponse{Houses: houses}, nil
+}
+
+func locationsResponse(houses []models.HouseLocation) models.HouseLocationsResponse {
+	if houses == nil {
+		houses = []models.HouseLocation{}
+	}
+	return models.HouseLocationsResponse{
+		Houses: houses,
+	}
+}
+
+func (h HouseFlatService) ArchiveHouse(ctx context.Context, id int) (models.House, Error) {
+	house, err := h.houseStorage.Archive(ctx, id)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return models.House{}, NewServiceError(StatusByError(err), ErrHouseNotFound, DatabaseErrorCode)
+		}
+		if errors.Is(err.Cause(), repository.ErrEntityArchived) {
+			return models.House{}, NewServiceError(Conflict, ErrHouseArchived, DatabaseErrorCode)
+		}
+		return models.House{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return house, nil
+}
+
+func (h HouseFlatService) Houses(ctx context.Context, req models.HouseListRequest, user models.User) (models.HouseListResponse, Error) {
+	if err := preparePage(&req.PageRequest, models.HouseSortID); err != nil {
+		return models.HouseListResponse{}, err
+	}
+
+	houses, err := h.houseStorage.List(ctx, req, user)
+	if err != nil {
+		return models.HouseListResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	houses, next := cutPage(houses, req.Limit, func(house models.House) models.Cursor {
+		return models.Cursor{Value: house.SortKey(req.SortBy), ID: house.ID}
+	})
+	if houses == nil {
+		houses = []models.House{}
+	}
+
+	return models.HouseListResponse{
+		Houses:     houses,
+		NextCursor: next,
+	}, nil
+}
+func (h HouseFlatService) FlatsList(ctx context.Context, req models.FlatListRequest, user models.User) (models.FlatListResponse, Error) {
+	if err := preparePage(&req.PageRequest, models.FlatSortID); err != nil {
+		return models.FlatListResponse{}, err
+	}
+
+	flats, err := h.flatStorage.List(ctx, req, user)
+	if err != nil {
+		return models.FlatListResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	flats, next := cutPage(flats, req.Limit, func(flat models.Flat) models.Cursor {
+		return models.Cursor{Value: flat.SortKey(req.SortBy), ID: flat.ID}
+	})
+	if flats == nil {
+		flats = []models.Flat{}
+	}
+	if err := h.attachModeration(ctx, flats, user); err != nil {
+		return models.FlatListResponse{}, err
+	}
+
+	return models.FlatListResponse{
+		Flats:      flats,
+		NextCursor: next,
+	}, nil
+}
+
+// UserFlats returns flats created by the user in all statuses
+func (h HouseFlatService) UserFlats(ctx context.Context, req models.FlatListRequest, user models.User) (models.FlatListResponse, Error) {
+	if user.ID == uuid.Nil {
+		return models.FlatListResponse{}, NewServiceError(Forbidden, ErrUnregisteredUser, PermissionErrorCode)
+	}
+	req.CreatedBy = &user.ID
+
+	return h.FlatsList(ctx, req, user)
+}
+
 func (h HouseFlatService) AddSubscriber(ctx context.Context, email string, id int) Error {
 	if err := h.subscriberService.Add(ctx, email, id); err != nil {
 		return err
 	}
 	return nil
 }
+
+// topFloor returns count of floors of the house, nil if it's unknown. Missing house is reported by the storage later
+func (h HouseFlatService) topFloor(ctx context.Context, houseID int) (*int, Error) {
+	house, err := h.houseStorage.Get(ctx, houseID)
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return nil, nil
+		}
+		return nil, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	if house.Floors == 0 {
+		return nil, nil
+	}
+	return &house.Floors, nil
+}
+
+// checkFloor validates floor of the flat against count of floors of the house
+func (h HouseFlatService) checkFloor(ctx context.Context, houseID int, floor *int) Error {
+	if floor == nil {
+		return nil
+	}
+	top, err := h.topFloor(ctx, houseID)
+	if err != nil {
+		return err
+	}
+	if top != nil && *floor > *top {
+		return NewServiceError(BadRequest, ErrFloorOutOfRange, ValidationErrorCode)
+	}
+	return nil
+}
+
+// checkFlatFloor validates new floor of the existing flat
+func (h HouseFlatService) checkFlatFloor(ctx context.Context, id int, floor *int) Error {
+	if floor == nil {
+		return nil
+	}
+	flat, err := h.flatStorage.Get(ctx, models.Flat{ID: id})
+	if err != nil {
+		return flatError(err)
+	}
+	return h.checkFloor(ctx, flat.HouseID, floor)
+}
@@ -20,8 +20,8 @@ var (
 
 func TestMain(m *testing.M) {
 	base := mock.NewBase()
-	fs, hs, ss := mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), service.NewMockSubscriberService()
-	srv = service.NewHouseFlatService(fs, hs, ss)
+	fs, hs, ds, ss := mock.NewFlatStorage(&base), mock.NewHouseStorage(&base), mock.NewDeveloperStorage(&base), service.NewMockSubscriberService()
+	srv = service.NewHouseFlatService(fs, hs, ds, ss)
 
 	for i := 0; i < 2; i++ {
 		flats = append(flats, models.Flat{ID: i + 1, HouseID: 1, Price: 123 + i, Room: 4 - i, Status: models.Created})
@@ -50,11 +50,12 @@ func TestAddFlatNonExistingHouse(t *testing.T) {
 }
 
 func TestAddFlats(t *testing.T) {
-	for _, flat := range flats {
-		_, err := srv.CreateFlat(context.Background(), models.FlatCreateRequest{HouseID: flat.HouseID, Price: &flat.Price, Room: flat.Room})
+	for i, flat := range flats {
+		resp, err := srv.CreateFlat(context.Background(), models.FlatCreateRequest{HouseID: flat.HouseID, Price: &flat.Price, Room: flat.Room})
 		if err != nil {
 			require.Equalf(t, true, false, "expected no error, actual %s", err.Cause())
 		}
+		flats[i].CreatedAt = resp.CreatedAt
 	}
 }
 
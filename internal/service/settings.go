@@ -0,0 +1,15 @@
+package service
+
+import "time"
+
+type ModerationSettings struct {
+	ClaimTimeout    time.Duration `envconfig:"CLAIM_TIMEOUT" default:"15m"`
+	ReleaseInterval time.Duration `envconfig:"RELEASE_INTERVAL" default:"1m"`
+}
+
+type TokenSettings struct {
+	TTL             time.Duration `envconfig:"TTL" default:"15m"`
+	RefreshTTL      time.Duration `envconfig:"REFRESH_TTL" default:"720h"`
+	Leeway          time.Duration `envconfig:"LEEWAY" default:"30s"`
+	CleanupInterval time.Duration `envconfig:"CLEANUP_INTERVAL" default:"1h"` // how often expired revoked tokens are deleted
+}
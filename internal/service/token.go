@@ -2,70 +2,356 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/antsrp/house_service/internal/domain/models"
 	"github.com/antsrp/house_service/internal/repository"
 	"github.com/antsrp/house_service/pkg/jwt"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultTokenTTL        = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+	refreshTokenLength     = 32
+)
+
+// names of claims of access token
+const (
+	claimSubject   = "sub"
+	claimRole      = "role"
+	claimID        = "jti"
+	claimSession   = "sid"
+	claimIssuedAt  = "iat"
+	claimExpiresAt = "exp"
 )
 
 type TokenServicer interface {
-	UserByToken(context.Context, string) (models.User, Error)
-	TokenByID(context.Context, models.User) (string, Error)
-	AddToken(context.Context, models.User, string) Error
+	ParseToken(context.Context, string) (models.Token, Error)
+	Refresh(context.Context, string, models.ClientInfo) (models.LoginResponse, Error)
+	Revoke(context.Context, models.Token) Error
+	RevokeUser(context.Context, uuid.UUID) Error
+	Sessions(context.Context, models.Token) (models.SessionsResponse, Error)
+	RevokeSession(context.Context, uuid.UUID, uuid.UUID) Error
+	DeleteExpiredRevoked(context.Context) (int, Error)
 }
 
 type TokenCreator interface {
-	CreateToken(context.Context, models.User) (string, Error)
+	CreateTokens(context.Context, models.User, models.ClientInfo) (models.LoginResponse, Error)
 }
 
 type TokenService struct {
 	tokenStorage repository.TokenStorage
 	jwtService   jwt.Servicer
+	ttl          time.Duration
+	refreshTTL   time.Duration
+	leeway       time.Duration
+}
+
+type TokenOption func(t *TokenService)
+
+// WithTokenTTL sets lifetime of issued access tokens
+func WithTokenTTL(ttl time.Duration) TokenOption {
+	return func(t *TokenService) {
+		t.ttl = ttl
+	}
+}
+
+// WithRefreshTokenTTL sets lifetime of issued refresh tokens
+func WithRefreshTokenTTL(ttl time.Duration) TokenOption {
+	return func(t *TokenService) {
+		t.refreshTTL = ttl
+	}
 }
 
-func NewTokenService(tokenStorage repository.TokenStorage, jwtService jwt.Servicer) TokenService {
-	return TokenService{
+// WithTokenLeeway sets allowed clock skew, expired tokens are valid during it
+func WithTokenLeeway(leeway time.Duration) TokenOption {
+	return func(t *TokenService) {
+		t.leeway = leeway
+	}
+}
+
+func NewTokenService(tokenStorage repository.TokenStorage, jwtService jwt.Servicer, opts ...TokenOption) TokenService {
+	t := TokenService{
 		tokenStorage: tokenStorage,
 		jwtService:   jwtService,
+		ttl:          DefaultTokenTTL,
+		refreshTTL:   DefaultRefreshTokenTTL,
+	}
+
+	for _, opt := range opts {
+		opt(&t)
 	}
+
+	return t
 }
 
-func (t TokenService) UserByToken(ctx context.Context, token string) (models.User, Error) {
-	user, err := t.tokenStorage.UserByToken(ctx, token)
+// ParseToken verifies signature and expiration of the token locally, database is only asked if the token is revoked
+func (t TokenService) ParseToken(ctx context.Context, token string) (models.Token, Error) {
+	claims, err := t.jwtService.Parse(token)
 	if err != nil {
-		return models.User{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+		return models.Token{}, NewServiceError(BadRequest, err, ParseTokenErrorCode)
+	}
+	parsed, err := tokenFromClaims(claims)
+	if err != nil {
+		return models.Token{}, NewServiceError(BadRequest, err, ParseTokenErrorCode)
 	}
 
-	return user, nil
+	revoked, derr := t.tokenStorage.IsRevoked(ctx, parsed)
+	if derr != nil {
+		return models.Token{}, NewServiceError(StatusByError(derr), derr.Cause(), DatabaseErrorCode)
+	}
+	if revoked {
+		return models.Token{}, NewServiceError(BadRequest, ErrTokenRevoked, ParseTokenErrorCode)
+	}
+
+	return parsed, nil
 }
-func (t TokenService) TokenByID(ctx context.Context, user models.User) (string, Error) {
-	token, err := t.tokenStorage.TokenByID(ctx, user)
+
+// DeleteExpiredRevoked forgets revoked access tokens which are rejected anyway because of expiration
+func (t TokenService) DeleteExpiredRevoked(ctx context.Context) (int, Error) {
+	cnt, err := t.tokenStorage.DeleteExpiredRevoked(ctx, time.Now().Add(-t.leeway))
 	if err != nil {
-		return "", NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+		return 0, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	}
+	return cnt, nil
+}
 
-	return token, nil
+// Revoke revokes the access token and the session it belongs to
+func (t TokenService) Revoke(ctx context.Context, token models.Token) Error {
+	if err := t.tokenStorage.RevokeToken(ctx, token); err != nil {
+		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	return nil
 }
 
-func (t TokenService) AddToken(ctx context.Context, user models.User, token string) Error {
-	if err := t.tokenStorage.AddToken(ctx, user, token, time.Now()); err != nil {
+// RevokeUser revokes all sessions of the user
+func (t TokenService) RevokeUser(ctx context.Context, id uuid.UUID) Error {
+	if err := t.tokenStorage.RevokeUserTokens(ctx, id, time.Now().Truncate(time.Microsecond)); err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return NewServiceError(StatusByError(err), ErrUserNotFound, DatabaseErrorCode)
+		}
 		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	}
+	return nil
+}
+
+// CreateTokens starts a new session of the user and issues access token and refresh token of it
+func (t TokenService) CreateTokens(ctx context.Context, user models.User, client models.ClientInfo) (models.LoginResponse, Error) {
+	now := time.Now()
+	session := newSession(uuid.New(), client, now, now.Add(t.refreshTTL))
+	session.UserID, session.CreatedAt = user.ID, now
+	if err := t.tokenStorage.AddSession(ctx, session); err != nil {
+		return models.LoginResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	return t.createTokens(user, session.ID, func(token models.RefreshToken) Error {
+		if err := t.tokenStorage.AddRefreshToken(ctx, token); err != nil {
+			return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+		}
+		return nil
+	})
+}
 
+// Sessions returns active sessions of the user the token belongs to, the session of the token is marked as current
+func (t TokenService) Sessions(ctx context.Context, token models.Token) (models.SessionsResponse, Error) {
+	sessions, err := t.tokenStorage.Sessions(ctx, token.UserID)
+	if err != nil {
+		return models.SessionsResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == token.SessionID
+	}
+
+	return models.SessionsResponse{Sessions: sessions}, nil
+}
+
+// RevokeSession revokes the session of the user with all its tokens
+func (t TokenService) RevokeSession(ctx context.Context, userID, id uuid.UUID) Error {
+	if err := t.tokenStorage.RevokeSession(ctx, userID, id); err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return NewServiceError(StatusByError(err), ErrSessionNotFound, DatabaseErrorCode)
+		}
+		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
 	return nil
 }
 
-func (t TokenService) CreateToken(ctx context.Context, user models.User) (string, Error) {
-	token, err := t.jwtService.NewToken(map[string]any{
-		"id":         user.ID,
-		"user_type":  user.UserType,
-		"created_at": time.Now(),
+// Refresh exchanges refresh token for a new pair. Refresh token can be used only once,
+// presenting already used token means it is stolen, so the whole family of tokens is revoked
+func (t TokenService) Refresh(ctx context.Context, refreshToken string, client models.ClientInfo) (models.LoginResponse, Error) {
+	token, err := t.tokenStorage.RefreshToken(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			return models.LoginResponse{}, NewServiceError(BadRequest, ErrInvalidToken, ParseTokenErrorCode)
+		}
+		return models.LoginResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+
+	if token.RevokedAt != nil {
+		return models.LoginResponse{}, NewServiceError(BadRequest, ErrTokenRevoked, ParseTokenErrorCode)
+	}
+	if token.UsedAt != nil {
+		return models.LoginResponse{}, t.revokeFamily(ctx, token)
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return models.LoginResponse{}, NewServiceError(BadRequest, ErrTokenExpired, ParseTokenErrorCode)
+	}
+
+	// the used token is replaced atomically, so a failure leaves it valid for retry
+	now := time.Now()
+	session := newSession(token.FamilyID, client, now, now.Add(t.refreshTTL))
+	return t.createTokens(models.User{ID: token.UserID, UserType: token.UserType}, token.FamilyID, func(next models.RefreshToken) Error {
+		err := t.tokenStorage.RotateRefreshToken(ctx, token.ID, session, next)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err.Cause(), repository.ErrNoRowsAffected): // concurrent refresh with the same token
+			return t.revokeFamily(ctx, token)
+		case errors.Is(err.Cause(), repository.ErrEntityNotFound): // session is revoked right now
+			return NewServiceError(BadRequest, ErrTokenRevoked, ParseTokenErrorCode)
+		}
+		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	})
+}
+
+func (t TokenService) revokeFamily(ctx context.Context, token models.RefreshToken) Error {
+	if err := t.tokenStorage.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+		return NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
+	}
+	return NewServiceError(BadRequest, ErrTokenReused, ParseTokenErrorCode)
+}
+
+// createTokens issues a pair of tokens of the family, refresh token is saved by store
+func (t TokenService) createTokens(user models.User, family uuid.UUID, store func(models.RefreshToken) Error) (models.LoginResponse, Error) {
+	access, expiresAt, err := t.createAccessToken(user, family)
 	if err != nil {
-		return "", NewServiceError(Internal, err, CreateTokenErrorCode)
+		return models.LoginResponse{}, err
 	}
+	token, refresh, err := t.newRefreshToken(user, family)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if err := store(token); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		DummyLoginResponse: models.DummyLoginResponse{
+			Token: access,
+		},
+		RefreshToken: refresh,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (t TokenService) createAccessToken(user models.User, session uuid.UUID) (string, time.Time, Error) {
+	now := time.Now()
+	token := models.Token{
+		ID:        uuid.New(),
+		SessionID: session,
+		UserID:    user.ID,
+		UserType:  user.UserType,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(t.ttl),
+	}
+
+	signed, err := t.jwtService.NewToken(map[string]any{
+		claimSubject:   token.UserID.String(),
+		claimRole:      token.UserType,
+		claimID:        token.ID.String(),
+		claimSession:   token.SessionID.String(),
+		claimIssuedAt:  float64(token.IssuedAt.UnixMicro()) / 1e6, // fractional, so revocation of all tokens of the user isn't rounded to a second
+		claimExpiresAt: token.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, NewServiceError(Internal, err, CreateTokenErrorCode)
+	}
+
+	return signed, token.ExpiresAt, nil
+}
+
+// newRefreshToken generates refresh token, its value is returned to the client and only hash of it is stored
+func (t TokenService) newRefreshToken(user models.User, family uuid.UUID) (models.RefreshToken, string, Error) {
+	raw := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(raw); err != nil {
+		return models.RefreshToken{}, "", NewServiceError(Internal, fmt.Errorf("can't generate refresh token: %w", err), CreateTokenErrorCode)
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	token := models.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  family,
+		UserID:    user.ID,
+		UserType:  user.UserType,
+		Hash:      hashRefreshToken(value),
+		CreatedAt: now,
+		ExpiresAt: now.Add(t.refreshTTL),
+	}
+
+	return token, value, nil
+}
+
+func newSession(id uuid.UUID, client models.ClientInfo, lastSeen, expiresAt time.Time) models.Session {
+	userAgent := []rune(client.UserAgent)
+	if len(userAgent) > models.MaxUserAgentLength {
+		userAgent = userAgent[:models.MaxUserAgentLength]
+	}
+	return models.Session{
+		ID:         id,
+		UserAgent:  string(userAgent),
+		IP:         client.IP,
+		LastSeenAt: lastSeen,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// hashRefreshToken returns a key the refresh token is stored with, the token has enough entropy to not need a salt
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func tokenFromClaims(claims map[string]any) (models.Token, error) {
+	var (
+		token models.Token
+		err   error
+	)
+
+	sub, _ := claims[claimSubject].(string)
+	if token.UserID, err = uuid.Parse(sub); err != nil {
+		return models.Token{}, fmt.Errorf("%w: bad subject: %w", ErrInvalidToken, err)
+	}
+	jti, _ := claims[claimID].(string)
+	if token.ID, err = uuid.Parse(jti); err != nil {
+		return models.Token{}, fmt.Errorf("%w: bad token id: %w", ErrInvalidToken, err)
+	}
+	sid, _ := claims[claimSession].(string)
+	if token.SessionID, err = uuid.Parse(sid); err != nil {
+		return models.Token{}, fmt.Errorf("%w: bad session id: %w", ErrInvalidToken, err)
+	}
+	role, _ := claims[claimRole].(string)
+	if role != models.Client && role != models.Moderator {
+		return models.Token{}, fmt.Errorf("%w: unknown role %q", ErrInvalidToken, role)
+	}
+	token.UserType = role
+
+	iat, _ := claims[claimIssuedAt].(float64)
+	exp, _ := claims[claimExpiresAt].(float64)
+	token.IssuedAt, token.ExpiresAt = time.UnixMicro(int64(math.Round(iat*1e6))), time.Unix(int64(exp), 0)
+
 	return token, nil
 }
 
-var _ TokenServicer = TokenService{}
+var (
+	_ TokenServicer = TokenService{}
+	_ TokenCreator  = TokenService{}
+)
@@ -0,0 +1,254 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/antsrp/house_service/internal/domain/models"
+	"github.com/antsrp/house_service/internal/repository/mock"
+	"github.com/antsrp/house_service/internal/service"
+	"github.com/antsrp/house_service/pkg/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenService(base *mock.Base, opts ...service.TokenOption) service.TokenService {
+	js := jwt.NewJwtService([]byte(`some key`), jwt.WithExpirationRequired)
+	return service.NewTokenService(mock.NewTokenStorage(base), js, opts...)
+}
+
+func TestTokenClaims(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base)
+	ctx := context.Background()
+
+	user := models.User{ID: uuid.New(), UserType: models.Moderator}
+	resp, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+	require.Nil(t, err)
+	token := resp.Token
+
+	claims, perr := jwt.NewJwtService([]byte(`some key`)).Parse(token)
+	require.NoError(t, perr)
+	for _, claim := range []string{"sub", "exp", "iat", "jti", "sid", "role"} {
+		require.Containsf(t, claims, claim, "token should contain claim %s", claim)
+	}
+
+	parsed, err := ts.ParseToken(ctx, token)
+	require.Nil(t, err)
+	require.Equal(t, user.ID, parsed.UserID)
+	require.Equal(t, user.UserType, parsed.UserType)
+
+	_, err = ts.ParseToken(ctx, token+"x")
+	require.NotNil(t, err)
+	require.Equal(t, service.BadRequest, err.Status())
+}
+
+func TestTokenExpired(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base, service.WithTokenTTL(-time.Minute))
+
+	resp, err := ts.CreateTokens(context.Background(), models.User{ID: uuid.New(), UserType: models.Client}, models.ClientInfo{})
+	require.Nil(t, err)
+	token := resp.Token
+
+	_, err = ts.ParseToken(context.Background(), token)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), jwt.ErrExpiredToken)
+}
+
+func TestTokenRevoked(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base)
+	ctx := context.Background()
+
+	resp, err := ts.CreateTokens(ctx, models.User{ID: uuid.New(), UserType: models.Client}, models.ClientInfo{})
+	require.Nil(t, err)
+	token := resp.Token
+
+	claims, perr := jwt.NewJwtService([]byte(`some key`)).Parse(token)
+	require.NoError(t, perr)
+	base.RevokeToken(uuid.MustParse(claims["jti"].(string)), time.Now().Add(time.Minute))
+
+	_, err = ts.ParseToken(ctx, token)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrTokenRevoked)
+
+	cnt, err := ts.DeleteExpiredRevoked(ctx)
+	require.Nil(t, err)
+	require.Zero(t, cnt, "revoked token isn't expired yet")
+
+	base.RevokeToken(uuid.New(), time.Now().Add(-time.Hour))
+	cnt, err = ts.DeleteExpiredRevoked(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 1, cnt)
+	_, err = ts.ParseToken(ctx, token)
+	require.NotNil(t, err, "not expired revoked token is kept")
+}
+
+func TestRefreshRotation(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base)
+	ctx := context.Background()
+
+	user := models.User{ID: uuid.New(), UserType: models.Client}
+	first, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+	require.Nil(t, err)
+	require.NotEmpty(t, first.RefreshToken)
+
+	second, err := ts.Refresh(ctx, first.RefreshToken, models.ClientInfo{})
+	require.Nil(t, err)
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	parsed, err := ts.ParseToken(ctx, second.Token)
+	require.Nil(t, err)
+	require.Equal(t, user.ID, parsed.UserID)
+
+	// reuse of rotated token revokes the whole family including the newest token
+	_, err = ts.Refresh(ctx, first.RefreshToken, models.ClientInfo{})
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrTokenReused)
+
+	_, err = ts.Refresh(ctx, second.RefreshToken, models.ClientInfo{})
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrTokenRevoked)
+	_, err = ts.ParseToken(ctx, second.Token)
+	require.NotNil(t, err, "access token of revoked session should be rejected")
+
+	// other sessions of the user are not affected
+	other, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+	require.Nil(t, err)
+	_, err = ts.Refresh(ctx, other.RefreshToken, models.ClientInfo{})
+	require.Nil(t, err)
+}
+
+func TestRefreshInvalid(t *testing.T) {
+	base := mock.NewBase()
+	ctx := context.Background()
+
+	_, err := newTokenService(&base).Refresh(ctx, "unknown", models.ClientInfo{})
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrInvalidToken)
+
+	ts := newTokenService(&base, service.WithRefreshTokenTTL(-time.Minute))
+	resp, err := ts.CreateTokens(ctx, models.User{ID: uuid.New(), UserType: models.Client}, models.ClientInfo{})
+	require.Nil(t, err)
+	_, err = ts.Refresh(ctx, resp.RefreshToken, models.ClientInfo{})
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrTokenExpired)
+}
+
+func TestLogout(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base)
+	ctx := context.Background()
+
+	user := models.User{ID: uuid.New(), UserType: models.Client}
+	current, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+	require.Nil(t, err)
+	other, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+	require.Nil(t, err)
+
+	token, err := ts.ParseToken(ctx, current.Token)
+	require.Nil(t, err)
+	require.Nil(t, ts.Revoke(ctx, token))
+
+	_, err = ts.ParseToken(ctx, current.Token)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrTokenRevoked)
+	_, err = ts.Refresh(ctx, current.RefreshToken, models.ClientInfo{})
+	require.NotNil(t, err)
+
+	_, err = ts.ParseToken(ctx, other.Token)
+	require.Nil(t, err, "other sessions should stay valid")
+}
+
+func TestLogoutAll(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base)
+	ctx := context.Background()
+
+	user := models.User{ID: uuid.New(), UserType: models.Client}
+	var sessions []models.LoginResponse
+	for i := 0; i < 2; i++ {
+		resp, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+		require.Nil(t, err)
+		sessions = append(sessions, resp)
+	}
+	another, err := ts.CreateTokens(ctx, models.User{ID: uuid.New(), UserType: models.Client}, models.ClientInfo{})
+	require.Nil(t, err)
+
+	require.Nil(t, ts.RevokeUser(ctx, user.ID))
+
+	for _, session := range sessions {
+		_, err := ts.ParseToken(ctx, session.Token)
+		require.NotNil(t, err)
+		require.ErrorIs(t, err.Cause(), service.ErrTokenRevoked)
+		_, err = ts.Refresh(ctx, session.RefreshToken, models.ClientInfo{})
+		require.NotNil(t, err)
+	}
+
+	_, err = ts.ParseToken(ctx, another.Token)
+	require.Nil(t, err, "tokens of another user should stay valid")
+
+	// login right after the revocation, in the same second, gets a valid token
+	resp, err := ts.CreateTokens(ctx, user, models.ClientInfo{})
+	require.Nil(t, err)
+	_, err = ts.ParseToken(ctx, resp.Token)
+	require.Nil(t, err, "token issued after revocation should be valid")
+}
+
+func TestSessions(t *testing.T) {
+	base := mock.NewBase()
+	ts := newTokenService(&base)
+	ctx := context.Background()
+
+	user := models.User{ID: uuid.New(), UserType: models.Client}
+	laptop, err := ts.CreateTokens(ctx, user, models.ClientInfo{UserAgent: "laptop", IP: "10.0.0.1"})
+	require.Nil(t, err)
+	phone, err := ts.CreateTokens(ctx, user, models.ClientInfo{UserAgent: "phone", IP: "10.0.0.2"})
+	require.Nil(t, err)
+
+	// login on the phone doesn't replace the laptop session
+	laptopToken, err := ts.ParseToken(ctx, laptop.Token)
+	require.Nil(t, err)
+	phoneToken, err := ts.ParseToken(ctx, phone.Token)
+	require.Nil(t, err)
+	require.NotEqual(t, laptopToken.SessionID, phoneToken.SessionID)
+
+	resp, err := ts.Sessions(ctx, laptopToken)
+	require.Nil(t, err)
+	require.Len(t, resp.Sessions, 2)
+	for _, session := range resp.Sessions {
+		require.Equal(t, session.ID == laptopToken.SessionID, session.Current)
+	}
+
+	// refresh updates metadata of the session
+	_, err = ts.Refresh(ctx, phone.RefreshToken, models.ClientInfo{UserAgent: "phone v2", IP: "10.0.0.3"})
+	require.Nil(t, err)
+	resp, err = ts.Sessions(ctx, phoneToken)
+	require.Nil(t, err)
+	require.Len(t, resp.Sessions, 2)
+	require.Equal(t, phoneToken.SessionID, resp.Sessions[0].ID, "the last seen session should be the first")
+	require.Equal(t, "phone v2", resp.Sessions[0].UserAgent)
+	require.Equal(t, "10.0.0.3", resp.Sessions[0].IP)
+
+	// sessions of another user can't be revoked
+	err = ts.RevokeSession(ctx, uuid.New(), phoneToken.SessionID)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrSessionNotFound)
+
+	require.Nil(t, ts.RevokeSession(ctx, user.ID, phoneToken.SessionID))
+	_, err = ts.ParseToken(ctx, phone.Token)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrTokenRevoked)
+
+	resp, err = ts.Sessions(ctx, laptopToken)
+	require.Nil(t, err)
+	require.Len(t, resp.Sessions, 1)
+	require.Equal(t, laptopToken.SessionID, resp.Sessions[0].ID)
+
+	err = ts.RevokeSession(ctx, user.ID, phoneToken.SessionID)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err.Cause(), service.ErrSessionNotFound)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 
 	"github.com/antsrp/house_service/internal/domain/models"
 	"github.com/antsrp/house_service/internal/repository"
@@ -15,54 +17,60 @@ type UserServicer interface {
 }
 
 type UserService struct {
-	tokenService TokenServicer
+	logger       *slog.Logger
 	tokenCreator TokenCreator
 	userStorage  repository.UserStorage
 	cryptor      crypt.Cryptor
+	dummyHash    string // is verified for unknown users, so they can't be told apart by response time
 }
 
-func NewUserService(tokenService TokenServicer, tokenCreator TokenCreator, userStorage repository.UserStorage, cryptor crypt.Cryptor) UserService {
+func NewUserService(logger *slog.Logger, tokenCreator TokenCreator, userStorage repository.UserStorage, cryptor crypt.Cryptor) UserService {
+	dummyHash, err := cryptor.Hash("dummy password")
+	if err != nil {
+		logger.Warn("can't hash dummy password, login of unknown users isn't slowed down", slog.Any("error", err))
+	}
 	return UserService{
-		tokenService: tokenService,
+		logger:       logger,
 		tokenCreator: tokenCreator,
 		userStorage:  userStorage,
 		cryptor:      cryptor,
+		dummyHash:    dummyHash,
 	}
 }
 
 func (u UserService) Login(ctx context.Context, req models.LoginRequest) (models.LoginResponse, Error) {
-	hash := u.cryptor.Hash(*req.Password)
-	user := models.User{
-		ID:       *req.UserID,
-		Password: hash,
-	}
-	var err repository.DatabaseError
-	if user, err = u.userStorage.Get(ctx, user); err != nil {
+	user, err := u.userStorage.Get(ctx, *req.UserID)
+	if err != nil {
 		if errors.Is(err.Cause(), repository.ErrEntityNotFound) {
+			_, _ = u.cryptor.Verify(*req.Password, u.dummyHash)
 			return models.LoginResponse{}, NewServiceError(StatusByError(err), ErrUserNotFound, DatabaseErrorCode)
 		}
 		return models.LoginResponse{}, NewServiceError(StatusByError(err), err.Cause(), DatabaseErrorCode)
 	}
-	token, terr := u.tokenService.TokenByID(ctx, user)
-	if terr != nil {
-		if !errors.Is(terr.Cause(), repository.ErrEntityNotFound) {
-			return models.LoginResponse{}, terr
-		}
-		if token, terr = u.tokenCreator.CreateToken(ctx, user); terr != nil {
-			return models.LoginResponse{}, terr
-		}
-		if err := u.tokenService.AddToken(ctx, user, token); err != nil {
-			return models.LoginResponse{}, err
+	ok, verr := u.cryptor.Verify(*req.Password, user.Password)
+	if verr != nil {
+		return models.LoginResponse{}, NewServiceError(Internal, fmt.Errorf("can't verify password: %w", verr), CryptoErrorCode)
+	}
+	if !ok { // wrong password is indistinguishable from unknown user
+		return models.LoginResponse{}, NewServiceError(BadRequest, ErrUserNotFound, DatabaseErrorCode)
+	}
+	if u.cryptor.NeedsRehash(user.Password) {
+		// upgrade of the hash is best effort, the user is logged in anyway and it'll be retried next time
+		hash, herr := u.cryptor.Hash(*req.Password)
+		if herr != nil {
+			u.logger.Warn("can't rehash password", slog.String("user_id", user.ID.String()), slog.Any("error", herr))
+		} else if err := u.userStorage.UpdatePassword(ctx, user.ID, hash); err != nil {
+			u.logger.Warn("can't update password hash", slog.String("user_id", user.ID.String()), slog.Any("error", err.Cause()))
 		}
 	}
-	return models.LoginResponse{
-		DummyLoginResponse: models.DummyLoginResponse{
-			Token: token,
-		},
-	}, nil
+	user.Password = ""
+	return u.tokenCreator.CreateTokens(ctx, user, req.Client)
 }
 func (u UserService) Register(ctx context.Context, req models.RegisterRequest) (models.RegisterResponse, Error) {
-	hash := u.cryptor.Hash(*req.Password)
+	hash, herr := u.cryptor.Hash(*req.Password)
+	if herr != nil {
+		return models.RegisterResponse{}, NewServiceError(Internal, fmt.Errorf("can't hash password: %w", herr), CryptoErrorCode)
+	}
 	user := models.User{
 		Email:    *req.Email,
 		Password: hash,
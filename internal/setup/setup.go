@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/antsrp/house_service/internal/repository/postgres"
 	"github.com/antsrp/house_service/internal/rest"
@@ -17,7 +18,8 @@ import (
 	"github.com/antsrp/house_service/pkg/log"
 )
 
-func Setup(dbConfigPrefix string) (rest.Handler, rs.Settings, *postgres.Connection, *slog.Logger, error) {
+// Setup builds the handler and its dependencies, background jobs are stopped when ctx is done
+func Setup(ctx context.Context, dbConfigPrefix string) (rest.Handler, rs.Settings, *postgres.Connection, *slog.Logger, error) {
 	logger := log.NewSlogTextLogger()
 
 	if err := config.Load(); err != nil {
@@ -39,26 +41,99 @@ func Setup(dbConfigPrefix string) (rest.Handler, rs.Settings, *postgres.Connecti
 		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot parse database settings: %w", err)
 	}
 
-	dbConnection, err := postgres.NewConnection(context.Background(), dbSettings, logger)
+	moderationSettings, err := config.Parse[service.ModerationSettings]("MODERATION")
+	if err != nil {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot parse moderation settings: %w", err)
+	}
+	if moderationSettings.ReleaseInterval <= 0 {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("moderation release interval must be positive, got %s", moderationSettings.ReleaseInterval)
+	}
+
+	dbConnection, err := postgres.NewConnection(ctx, dbSettings, logger)
 	if err != nil {
 		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot init database connection: %w", err)
 	}
 	//defer dbConnection.Close()
 	hs, fs, ss := postgres.NewHouseStorage(*dbConnection), postgres.NewFlatStorage(*dbConnection), postgres.NewSubscriberStorage(*dbConnection)
-
+	ds := postgres.NewDeveloperStorage(*dbConnection)
 	subscriberService := service.NewSubscriberService(logger, ss)
 
-	HFService := service.NewHouseFlatService(fs, hs, subscriberService)
+	HFService := service.NewHouseFlatService(fs, hs, ds, subscriberService, service.WithClaimTimeout(moderationSettings.ClaimTimeout))
+	developerService := service.NewDeveloperService(ds, HFService)
+	reportService := service.NewReportService(postgres.NewReportStorage(*dbConnection))
 
-	jwtService := jwt.NewJwtService(key)
-	var cryptor crypt.Crypt
+	tokenSettings, err := config.Parse[service.TokenSettings]("TOKEN")
+	if err != nil {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot parse token settings: %w", err)
+	}
+	if tokenSettings.CleanupInterval <= 0 {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("token cleanup interval must be positive, got %s", tokenSettings.CleanupInterval)
+	}
+	jwtService := jwt.NewJwtService(key, jwt.WithExpirationRequired, jwt.WithIssuedAt, jwt.WithLeeway(tokenSettings.Leeway))
+	cryptSettings, err := config.Parse[crypt.Settings]("PASSWORD")
+	if err != nil {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot parse password hashing settings: %w", err)
+	}
+	cryptor, err := crypt.FromSettings(cryptSettings)
+	if err != nil {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot init password hashing: %w", err)
+	}
 	tokenStorage := postgres.NewTokenStorage(*dbConnection)
-	tokenService := service.NewTokenService(tokenStorage, jwtService)
+	tokenService := service.NewTokenService(tokenStorage, jwtService, service.WithTokenTTL(tokenSettings.TTL), service.WithRefreshTokenTTL(tokenSettings.RefreshTTL),
+		service.WithTokenLeeway(tokenSettings.Leeway))
 
 	userStorage := postgres.NewUserStorage(*dbConnection)
-	userService := service.NewUserService(tokenService, tokenService, userStorage, cryptor)
+	userService := service.NewUserService(logger, tokenService, userStorage, cryptor)
 
-	h := rest.NewHandler(logger, srvSettings, HFService, userService, tokenService)
+	h, err := rest.NewHandler(logger, srvSettings, HFService, developerService, reportService, userService, tokenService)
+	if err != nil {
+		return rest.Handler{}, rs.Settings{}, nil, logger, fmt.Errorf("cannot init handler: %w", err)
+	}
+
+	go releaseExpiredModeration(ctx, HFService, moderationSettings.ReleaseInterval, logger)
+	go deleteExpiredRevokedTokens(ctx, tokenService, tokenSettings.CleanupInterval, logger)
 
 	return h, srvSettings, dbConnection, logger, nil
 }
+
+// releaseExpiredModeration periodically returns flats with stale moderation back to the queue
+func releaseExpiredModeration(ctx context.Context, srv service.HouseFlatServicer, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cnt, err := srv.ReleaseExpiredModeration(ctx)
+			if err != nil {
+				logger.Error("cannot release flats with expired moderation", slog.Any("error", err.Cause()))
+				continue
+			}
+			if cnt > 0 {
+				logger.Info(fmt.Sprintf("%d flats with expired moderation are released", cnt))
+			}
+		}
+	}
+}
+
+// deleteExpiredRevokedTokens periodically deletes revoked tokens which are expired anyway
+func deleteExpiredRevokedTokens(ctx context.Context, srv service.TokenServicer, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cnt, err := srv.DeleteExpiredRevoked(ctx)
+			if err != nil {
+				logger.Error("cannot delete expired revoked tokens", slog.Any("error", err.Cause()))
+				continue
+			}
+			if cnt > 0 {
+				logger.Info(fmt.Sprintf("%d expired revoked tokens are deleted", cnt))
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package crypt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2Prefix = "$argon2id$"
+
+// Argon2 hashes passwords with argon2id, hashes are encoded in PHC string format with parameters and salt
+type Argon2 struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+var _ Algorithm = Argon2{}
+
+// NewArgon2 returns argon2id with parameters recommended by RFC 9106 for memory constrained environments
+func NewArgon2() Argon2 {
+	return Argon2{
+		Time:    1,
+		Memory:  64 * 1024,
+		Threads: 4,
+		KeyLen:  32,
+		SaltLen: 16,
+	}
+}
+
+func (a Argon2) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("can't generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// decode parses parameters, salt and key of the hash
+func (a Argon2) decode(hash string) (Argon2, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || !a.Recognizes(hash) {
+		return Argon2{}, nil, nil, ErrUnknownHash
+	}
+	var (
+		version int
+		params  Argon2
+	)
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2{}, nil, nil, fmt.Errorf("unsupported version of argon2: %s", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Argon2{}, nil, nil, fmt.Errorf("can't parse parameters of argon2: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2{}, nil, nil, fmt.Errorf("can't decode salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2{}, nil, nil, fmt.Errorf("can't decode key: %w", err)
+	}
+	params.KeyLen, params.SaltLen = uint32(len(key)), uint32(len(salt))
+
+	return params, salt, key, nil
+}
+
+func (a Argon2) Verify(password, hash string) (bool, error) {
+	params, salt, key, err := a.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a Argon2) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, argon2Prefix)
+}
+
+func (a Argon2) Outdated(hash string) bool {
+	params, _, _, err := a.decode(hash)
+	return err != nil || params != a
+}
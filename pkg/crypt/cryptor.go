@@ -1,20 +1,96 @@
 package crypt
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"errors"
+	"fmt"
 )
 
+// Cryptor hashes passwords and verifies them against stored hashes
 type Cryptor interface {
-	Hash(string) string
+	Hash(string) (string, error)
+	Verify(password, hash string) (bool, error)
+	NeedsRehash(string) bool // reports whether hash should be replaced by a hash of the current algorithm
 }
 
+// Algorithm is a password hashing algorithm with self-describing hashes
+type Algorithm interface {
+	Hash(string) (string, error)
+	Verify(password, hash string) (bool, error)
+	Recognizes(string) bool // reports whether hash is produced by the algorithm
+	Outdated(string) bool   // reports whether hash is produced with another parameters
+}
+
+var ErrUnknownHash = errors.New("unknown format of hash")
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+type Settings struct {
+	Algorithm     string `envconfig:"ALGORITHM" default:"argon2id"`
+	BcryptCost    int    `envconfig:"BCRYPT_COST" default:"10"`
+	Argon2Time    uint32 `envconfig:"ARGON2_TIME" default:"1"`
+	Argon2Memory  uint32 `envconfig:"ARGON2_MEMORY" default:"65536"` // in KiB
+	Argon2Threads uint8  `envconfig:"ARGON2_THREADS" default:"4"`
+}
+
+// Crypt hashes passwords with the current algorithm and verifies hashes of every known one
 type Crypt struct {
+	current Algorithm
+	known   []Algorithm
 }
 
 var _ Cryptor = Crypt{}
 
-func (crypt Crypt) Hash(s string) string {
-	data := md5.Sum([]byte(s))
-	return hex.EncodeToString(data[:])
+type Option func(c *Crypt)
+
+// WithAlgorithm sets algorithm of new hashes
+func WithAlgorithm(a Algorithm) Option {
+	return func(c *Crypt) {
+		c.current = a
+	}
+}
+
+// NewCrypt creates cryptor which uses argon2id by default, bcrypt and legacy md5 hashes are verified as well
+func NewCrypt(opts ...Option) Crypt {
+	c := Crypt{
+		current: NewArgon2(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	c.known = []Algorithm{c.current, NewArgon2(), NewBcrypt(), MD5{}}
+
+	return c
+}
+
+// FromSettings creates cryptor with algorithm and parameters from settings
+func FromSettings(settings Settings) (Crypt, error) {
+	switch settings.Algorithm {
+	case AlgorithmArgon2id:
+		a := NewArgon2()
+		a.Time, a.Memory, a.Threads = settings.Argon2Time, settings.Argon2Memory, settings.Argon2Threads
+		return NewCrypt(WithAlgorithm(a)), nil
+	case AlgorithmBcrypt:
+		return NewCrypt(WithAlgorithm(Bcrypt{Cost: settings.BcryptCost})), nil
+	}
+	return Crypt{}, fmt.Errorf("unknown password hashing algorithm %q", settings.Algorithm)
+}
+
+func (c Crypt) Hash(password string) (string, error) {
+	return c.current.Hash(password)
+}
+
+func (c Crypt) Verify(password, hash string) (bool, error) {
+	for _, a := range c.known {
+		if a.Recognizes(hash) {
+			return a.Verify(password, hash)
+		}
+	}
+	return false, ErrUnknownHash
+}
+
+func (c Crypt) NeedsRehash(hash string) bool {
+	return !c.current.Recognizes(hash) || c.current.Outdated(hash)
 }
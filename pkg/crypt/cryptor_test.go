@@ -5,6 +5,7 @@ import (
 
 	"github.com/antsrp/house_service/pkg/crypt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var cryptor crypt.Cryptor
@@ -15,7 +16,7 @@ const (
 )
 
 func TestMain(m *testing.M) {
-	cryptor = crypt.Crypt{}
+	cryptor = crypt.NewCrypt()
 	m.Run()
 }
 
@@ -32,11 +33,60 @@ func TestCrypt(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actual := cryptor.Hash(test.a) == cryptor.Hash(test.b)
-		relation := "equal"
+		hash, err := cryptor.Hash(test.a)
+		require.NoError(t, err)
+		actual, err := cryptor.Verify(test.b, hash)
+		require.NoError(t, err)
+		relation := "match"
 		if !test.expected {
 			relation = "not " + relation
 		}
-		assert.Equalf(t, test.expected, actual, "hash of %s and %s should be %s", test.a, test.b, relation)
+		assert.Equalf(t, test.expected, actual, "password %s should %s hash of %s", test.b, relation, test.a)
 	}
 }
+
+func TestSalt(t *testing.T) {
+	first, err := cryptor.Hash(a)
+	require.NoError(t, err)
+	second, err := cryptor.Hash(a)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.False(t, cryptor.NeedsRehash(first))
+}
+
+func TestLegacyHashes(t *testing.T) {
+	legacy, _ := crypt.MD5{}.Hash(a)
+	bcrypt, err := crypt.Bcrypt{Cost: 4}.Hash(a)
+	require.NoError(t, err)
+
+	for _, hash := range []string{legacy, bcrypt} {
+		ok, err := cryptor.Verify(a, hash)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = cryptor.Verify(b, hash)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.True(t, cryptor.NeedsRehash(hash), "hash %s should be rehashed", hash)
+	}
+
+	weak := crypt.NewArgon2()
+	weak.Memory = 1024
+	hash, err := weak.Hash(a)
+	require.NoError(t, err)
+	assert.True(t, cryptor.NeedsRehash(hash))
+
+	_, err = cryptor.Verify(a, "not a hash")
+	assert.ErrorIs(t, err, crypt.ErrUnknownHash)
+}
+
+func TestFromSettings(t *testing.T) {
+	c, err := crypt.FromSettings(crypt.Settings{Algorithm: crypt.AlgorithmBcrypt, BcryptCost: 4})
+	require.NoError(t, err)
+	hash, err := c.Hash(a)
+	require.NoError(t, err)
+	assert.True(t, crypt.Bcrypt{}.Recognizes(hash))
+	assert.False(t, c.NeedsRehash(hash))
+
+	_, err = crypt.FromSettings(crypt.Settings{Algorithm: "md5"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package crypt
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// MD5 is unsalted md5 hex digest used by the first versions of the service, it's kept only to verify old hashes
+type MD5 struct {
+}
+
+var _ Algorithm = MD5{}
+
+func (m MD5) Hash(s string) (string, error) {
+	data := md5.Sum([]byte(s))
+	return hex.EncodeToString(data[:]), nil
+}
+
+func (m MD5) Verify(password, hash string) (bool, error) {
+	other, _ := m.Hash(password)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(other)) == 1, nil
+}
+
+func (m MD5) Recognizes(hash string) bool {
+	_, err := hex.DecodeString(hash)
+	return len(hash) == 2*md5.Size && err == nil
+}
+
+func (m MD5) Outdated(string) bool {
+	return false
+}
@@ -1,6 +1,7 @@
 package rest
 
 type Settings struct {
-	Host string `envconfig:"HOST"`
-	Port string `envconfig:"PORT"`
+	Host           string   `envconfig:"HOST"`
+	Port           string   `envconfig:"PORT"`
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"` // forwarding headers of other clients are ignored
 }
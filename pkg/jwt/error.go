@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrInvalidToken     = errors.New("invalid token")
 	ErrUnexpectedMethod = errors.New("unexpected method")
+	ErrExpiredToken     = errors.New("token is expired")
 )
@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,8 +13,9 @@ type Servicer interface {
 }
 
 type Service struct {
-	method  *jwt.SigningMethodHMAC
-	signKey []byte
+	method      *jwt.SigningMethodHMAC
+	signKey     []byte
+	parseOption []jwt.ParserOption
 }
 
 var _ Servicer = Service{}
@@ -42,8 +44,11 @@ func (js Service) Parse(token string) (map[string]any, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", jwtToken.Header["alg"])
 		}
 		return js.signKey, nil
-	})
+	}, js.parseOption...)
 	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
 		return nil, fmt.Errorf("can't parse jwt token: %w", err)
 	}
 
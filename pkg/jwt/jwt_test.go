@@ -2,6 +2,7 @@ package jwt_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/antsrp/house_service/pkg/jwt"
 	"github.com/stretchr/testify/require"
@@ -26,3 +27,46 @@ func Test1(t *testing.T) {
 		require.Equalf(t, v, parsed[k], "value of key %s should be %v, actual %v", k, v, parsed[k])
 	}
 }
+
+func TestExpiration(t *testing.T) {
+	service := jwt.NewJwtService([]byte(`some key`), jwt.WithExpirationRequired)
+
+	token, err := service.NewToken(map[string]any{"sub": "user"})
+	require.NoError(t, err)
+	_, err = service.Parse(token)
+	require.Error(t, err, "token without exp claim should be rejected")
+
+	token, err = service.NewToken(map[string]any{"sub": "user", "exp": time.Now().Add(-time.Minute).Unix()})
+	require.NoError(t, err)
+	_, err = service.Parse(token)
+	require.ErrorIs(t, err, jwt.ErrExpiredToken)
+
+	token, err = service.NewToken(map[string]any{"sub": "user", "exp": time.Now().Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	parsed, err := service.Parse(token)
+	require.NoError(t, err)
+	require.Equal(t, "user", parsed["sub"])
+}
+
+func TestIssuedAt(t *testing.T) {
+	service := jwt.NewJwtService([]byte(`some key`), jwt.WithIssuedAt)
+
+	token, err := service.NewToken(map[string]any{"iat": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	_, err = service.Parse(token)
+	require.Error(t, err, "token issued in the future should be rejected")
+
+	token, err = service.NewToken(map[string]any{"sub": "user"})
+	require.NoError(t, err)
+	_, err = service.Parse(token)
+	require.NoError(t, err, "exp claim isn't required by the option")
+}
+
+func TestLeeway(t *testing.T) {
+	service := jwt.NewJwtService([]byte(`some key`), jwt.WithExpirationRequired, jwt.WithLeeway(time.Minute))
+
+	token, err := service.NewToken(map[string]any{"exp": time.Now().Add(-time.Second * 10).Unix()})
+	require.NoError(t, err)
+	_, err = service.Parse(token)
+	require.NoError(t, err, "token expired within leeway should be accepted")
+}
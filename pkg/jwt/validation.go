@@ -0,0 +1,24 @@
+package jwt
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// WithExpirationRequired makes tokens without exp claim invalid
+func WithExpirationRequired(s *Service) {
+	s.parseOption = append(s.parseOption, jwt.WithExpirationRequired())
+}
+
+// WithIssuedAt makes tokens with iat claim in the future invalid
+func WithIssuedAt(s *Service) {
+	s.parseOption = append(s.parseOption, jwt.WithIssuedAt())
+}
+
+// WithLeeway allows clock skew between issuer and verifier when checking exp, iat and nbf claims
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(s *Service) {
+		s.parseOption = append(s.parseOption, jwt.WithLeeway(leeway))
+	}
+}